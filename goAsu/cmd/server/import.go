@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"goAsu/internal/config"
+	"goAsu/internal/database"
+	"goAsu/internal/importer"
+	"goAsu/internal/models"
+	"goAsu/internal/repository/postgres"
+	"goAsu/internal/validation"
+	"os"
+)
+
+const importUsage = "usage: server import [-dry-run] [-timeout DURATION] histories|plans FILE [flags]"
+
+// runImport выполняет подкоманду import: загружает историю или планы скважин
+// за день из файла CSV или XLSX. Формат определяется по расширению файла.
+// После имени файла можно указать те же флаги, что и для запуска сервера.
+// Весь файл записывается одним обращением к базе данных, поэтому
+// database.query_timeout к загрузке не применяется: ее время ограничивает
+// только флаг -timeout (по умолчанию без ограничения).
+func runImport(name string, args []string) error {
+	fs := flag.NewFlagSet(name+" import", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "только проверить файл, не сохраняя данные")
+	timeout := fs.Duration("timeout", 0, "предельное время загрузки (0 — без ограничения)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	if len(args) < 2 {
+		return errors.New(importUsage)
+	}
+	kind, path := args[0], args[1]
+	if kind != "histories" && kind != "plans" {
+		return errors.New(importUsage)
+	}
+	format, ok := importer.FormatFromName(path)
+	if !ok {
+		return fmt.Errorf("import: %s: unsupported file format, use .csv or .xlsx", path)
+	}
+
+	cfg, err := config.Load(name+" import", args[2:])
+	if err != nil {
+		return err
+	}
+	if cfg.Storage != "postgres" {
+		return fmt.Errorf("import: storage %q is not supported", cfg.Storage)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	db, err := database.Connect(context.Background(), cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	store := postgres.New(db, 0)
+	validator := validation.New(cfg.Validation, store.Wells)
+
+	var result models.ImportResult
+	if kind == "histories" {
+		result, err = importer.Histories(ctx, store.Histories, validator, format, file, *dryRun)
+	} else {
+		result, err = importer.Plans(ctx, store.Plans, validator, format, file, *dryRun)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, e := range result.Errors {
+		if e.Column != "" {
+			fmt.Fprintf(os.Stderr, "row %d, %s: %s\n", e.Row, e.Column, e.Message)
+		} else {
+			fmt.Fprintf(os.Stderr, "row %d: %s\n", e.Row, e.Message)
+		}
+	}
+	switch {
+	case len(result.Errors) > 0 && *dryRun:
+		return fmt.Errorf("import: %d rows checked, %d errors found", result.Rows, len(result.Errors))
+	case len(result.Errors) > 0:
+		return fmt.Errorf("import: %d errors found, nothing imported", len(result.Errors))
+	case *dryRun:
+		fmt.Printf("%d rows checked, no errors found\n", result.Rows)
+	default:
+		fmt.Printf("%d rows imported\n", result.Imported)
+	}
+	return nil
+}
@@ -1,39 +1,186 @@
-package main
-
-import (
-	"goAsu/internal/database"
-	"goAsu/internal/handlers"
-	"goAsu/internal/models"
-	"log"
-	"net/http"
-
-	_ "goAsu/docs"
-
-	_ "github.com/lib/pq"
-	httpSwagger "github.com/swaggo/http-swagger"
-)
-
-// @title NeftDobicha API
-// @version 1.0
-// @description REST API для обработки данных, хранимых в СУБД предприятия "НефтьДобыча".
-
-// @host localhost:8080
-// @BasePath /
-func main() {
-	db := database.InitDB(models.BASE_IP,
-		models.PORT,
-		models.USERNAME,
-		models.PASSWORD,
-		models.BASENAME)
-	defer db.Close()
-
-	http.HandleFunc("/objects", handlers.ObjectsHandler(db))
-	http.HandleFunc("/wells", handlers.WellsHandler(db))
-	http.HandleFunc("/well_day_histories", handlers.WellDayHistoriesHandler(db))
-	http.HandleFunc("/well_day_plans", handlers.WellDayPlansHandler(db))
-
-	// Добавьте маршрут для Swagger UI
-	http.HandleFunc("/swagger/", httpSwagger.WrapHandler)
-
-	log.Fatal(http.ListenAndServe(":8080", nil))
-}
+package main
+
+import (
+	"context"
+	"database/sql"
+	"goAsu/internal/auth"
+	"goAsu/internal/config"
+	"goAsu/internal/database"
+	"goAsu/internal/handlers"
+	"goAsu/internal/idempotency"
+	"goAsu/internal/metrics"
+	"goAsu/internal/middleware"
+	"goAsu/internal/models"
+	"goAsu/internal/repository"
+	"goAsu/internal/repository/memory"
+	"goAsu/internal/repository/postgres"
+	"goAsu/internal/server"
+	"goAsu/internal/tracing"
+	"goAsu/internal/validation"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+
+	_ "goAsu/docs"
+
+	_ "github.com/lib/pq"
+	httpSwagger "github.com/swaggo/http-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// @title NeftDobicha API
+// @version 1.0
+// @description REST API для обработки данных, хранимых в СУБД предприятия "НефтьДобыча".
+
+// @host localhost:8080
+// @BasePath /
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description Ключ API из секции auth.api_keys конфигурации.
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT в формате "Bearer <token>". Роли передаются в утверждении roles: viewer, operator, planner, admin.
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			if err := runMigrate(os.Args[0], os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		case "import":
+			if err := runImport(os.Args[0], os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
+	// Журнал сервера, включая журнал запросов, пишется в формате JSON.
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, nil)))
+
+	cfg, err := config.Load(os.Args[0], os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := cfg.ValidateAuth(); err != nil {
+		log.Fatal(err)
+	}
+	authn, err := auth.New(cfg.Auth)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !cfg.Auth.Enabled {
+		slog.Warn("Authentication is disabled, all requests are served with admin rights")
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var store *repository.Store
+	var db *sql.DB
+	ready := func(ctx context.Context) models.Readiness {
+		return models.Readiness{Status: models.Ready, Storage: cfg.Storage}
+	}
+	switch cfg.Storage {
+	case "memory":
+		log.Println("Using in-memory demo storage")
+		store = memory.NewDemo()
+	default:
+		db, err = database.Connect(context.Background(), cfg.Database)
+		if err != nil {
+			log.Fatal(err)
+		}
+		store = postgres.New(db, cfg.Database.QueryTimeout)
+		metrics.RegisterDB(db)
+		ready = func(ctx context.Context) models.Readiness {
+			return database.Check(ctx, db)
+		}
+	}
+	validator := validation.New(cfg.Validation, store.Wells)
+
+	// Ключи идемпотентности разделяются по клиенту, поэтому они обрабатываются
+	// после проверки подлинности.
+	idempotent := idempotency.New(cfg.Server.IdempotencyTTL)
+	protect := func(write auth.Permission, next http.Handler) http.Handler {
+		return authn.Protect(write, idempotent.Middleware(next))
+	}
+	http.Handle("/objects", protect(auth.WriteDirectories, handlers.ObjectsHandler(store.Objects, store.ObjectTypes)))
+	http.Handle("/object_types", protect(auth.WriteDirectories, handlers.ObjectTypesHandler(store.ObjectTypes)))
+	http.Handle("/wells", protect(auth.WriteDirectories, handlers.WellsHandler(store.Wells)))
+	http.Handle("/well_day_histories", protect(auth.WriteHistories, handlers.WellDayHistoriesHandler(store.Histories, validator)))
+	http.Handle("/well_day_histories/versions", protect(auth.Read, handlers.WellDayHistoryVersionsHandler(store.Histories)))
+	http.Handle("/well_day_plans", protect(auth.WritePlans, handlers.WellDayPlansHandler(store.Plans, validator)))
+	http.Handle("/well_day_histories/import", protect(auth.WriteHistories, handlers.WellDayHistoriesImportHandler(store.Histories, validator)))
+	http.Handle("/well_day_plans/import", protect(auth.WritePlans, handlers.WellDayPlansImportHandler(store.Plans, validator)))
+	http.Handle("/objects/batch", protect(auth.WriteDirectories, handlers.ObjectsBatchHandler(store.Objects, store.ObjectTypes)))
+	http.Handle("/wells/batch", protect(auth.WriteDirectories, handlers.WellsBatchHandler(store.Wells)))
+	http.Handle("/well_day_histories/batch", protect(auth.WriteHistories, handlers.WellDayHistoriesBatchHandler(store.Histories, validator)))
+	http.Handle("/well_day_plans/batch", protect(auth.WritePlans, handlers.WellDayPlansBatchHandler(store.Plans, validator)))
+	http.Handle("/objects/{id}", protect(auth.WriteDirectories, handlers.ObjectHandler(store.Objects, store.ObjectTypes)))
+	http.Handle("/object_types/{id}", protect(auth.WriteDirectories, handlers.ObjectTypeHandler(store.ObjectTypes)))
+	http.Handle("/wells/{well}", protect(auth.WriteDirectories, handlers.WellHandler(store.Wells)))
+	http.Handle("/wells/{well}/card", protect(auth.Read, handlers.WellCardHandler(store.Reports)))
+	http.Handle("/wells/{well}/history/{date}", protect(auth.WriteHistories, handlers.WellDayHistoryHandler(store.Histories, validator)))
+	http.Handle("/wells/{well}/plans/{date}", protect(auth.WritePlans, handlers.WellDayPlanHandler(store.Plans, validator)))
+	http.Handle("/reports/plan_fact", protect(auth.Read, handlers.PlanFactReportHandler(store.Reports)))
+	http.Handle("/reports/rollup", protect(auth.Read, handlers.ProductionRollupHandler(store.Reports)))
+	http.Handle("/audit", protect(auth.Read, handlers.AuditHandler(store.Audit)))
+
+	// Проверки для оркестратора не требуют аутентификации.
+	http.Handle("/healthz", handlers.HealthzHandler())
+	http.Handle("/readyz", handlers.ReadyzHandler(ready))
+	http.Handle("/metrics", metrics.Handler())
+
+	// Добавьте маршрут для Swagger UI
+	http.HandleFunc("/swagger/", httpSwagger.WrapHandler)
+
+	handler := server.LimitBody(cfg.Server.MaxBodySize, http.DefaultServeMux)
+	handler = metrics.Middleware(http.DefaultServeMux, handler)
+	handler = middleware.RequestID(middleware.AccessLog(slog.Default(), http.DefaultServeMux, handler))
+	// Span запроса создается раньше остальных обработчиков, чтобы журнал
+	// запросов и обращения к базе данных попали в одну трассировку.
+	// Проверки и метрики не трассируются.
+	handler = otelhttp.NewHandler(handler, "http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + middleware.Route(http.DefaultServeMux, r)
+		}),
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			switch r.URL.Path {
+			case "/healthz", "/readyz", "/metrics":
+				return false
+			}
+			return true
+		}),
+	)
+	runErr := server.Run(server.New(cfg.Server, handler), cfg.Server)
+	if runErr != nil {
+		log.Println(runErr)
+	}
+	// Запросы завершены, соединения с базой данных больше не нужны.
+	// Ресурсы освобождаются и при ошибке сервера: os.Exit не выполняет
+	// отложенные вызовы, поэтому выход откладывается до конца очистки.
+	if db != nil {
+		if err := db.Close(); err != nil {
+			log.Println(err)
+		}
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	err = shutdownTracing(shutdownCtx)
+	cancel()
+	if err != nil {
+		log.Println(err)
+	}
+	if runErr != nil {
+		os.Exit(1)
+	}
+	log.Println("Server stopped")
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goAsu/internal/config"
+	"goAsu/internal/database"
+	"goAsu/internal/migrations"
+	"strconv"
+)
+
+const migrateUsage = "usage: server migrate up|down [N]|status [flags]"
+
+// runMigrate выполняет подкоманду migrate:
+//
+//	migrate up        применяет все непримененные миграции;
+//	migrate down [N]  откатывает N последних миграций (по умолчанию одну);
+//	migrate status    выводит список миграций и отметку о применении.
+//
+// После действия можно указать те же флаги, что и для запуска сервера.
+func runMigrate(name string, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	action, args := args[0], args[1:]
+
+	steps := 1
+	if action == "down" && len(args) > 0 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
+			if n < 1 {
+				return fmt.Errorf("migrate down: N must be positive")
+			}
+			steps, args = n, args[1:]
+		}
+	}
+
+	cfg, err := config.Load(name+" migrate "+action, args)
+	if err != nil {
+		return err
+	}
+	if cfg.Storage != "postgres" {
+		return fmt.Errorf("migrate: storage %q does not use migrations", cfg.Storage)
+	}
+
+	db, err := database.Connect(context.Background(), cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	switch action {
+	case "up":
+		applied, err := migrations.Up(ctx, db)
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("no migrations to apply")
+		}
+		return err
+	case "down":
+		reverted, err := migrations.Down(ctx, db, steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(reverted) == 0 {
+			fmt.Println("no migrations to revert")
+		}
+		return err
+	case "status":
+		statuses, err := migrations.List(ctx, db)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
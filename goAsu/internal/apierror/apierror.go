@@ -0,0 +1,72 @@
+// Package apierror записывает ответы об ошибках в едином формате JSON:
+//
+//	{"code": "not_found", "message": "Not found", "details": ..., "request_id": "..."}
+//
+// code — стабильный машиночитаемый код, на который может опираться клиент;
+// message — описание для человека; details — необязательные подробности,
+// например список недопустимых полей; request_id — идентификатор запроса
+// из заголовка X-Request-ID.
+package apierror
+
+import (
+	"encoding/json"
+	"goAsu/internal/middleware"
+	"goAsu/internal/models"
+	"net/http"
+)
+
+const (
+	BadRequest        = "bad_request"
+	Unauthorized      = "unauthorized"
+	Forbidden         = "forbidden"
+	NotFound          = "not_found"
+	MethodNotAllowed  = "method_not_allowed"
+	Conflict          = "conflict"
+	InUse             = "in_use"
+	InvalidReference  = "invalid_reference"
+	InvalidValue      = "invalid_value"
+	ValidationFailed  = "validation_failed"
+	UnsupportedFormat = "unsupported_format"
+	PayloadTooLarge   = "payload_too_large"
+	Internal          = "internal_error"
+
+	// Canceled — клиент отменил запрос или закрыл соединение до ответа.
+	Canceled = "canceled"
+	// Timeout — обращение к базе данных не уложилось в отведенное время.
+	Timeout = "timeout"
+
+	// PreconditionFailed — запись не соответствует заголовку If-Match
+	// или If-None-Match: ее изменил другой клиент или она уже существует.
+	PreconditionFailed = "precondition_failed"
+
+	// ConcurrentUpdate — запись все время изменяют другие запросы,
+	// и патч не удалось применить за несколько попыток.
+	ConcurrentUpdate = "concurrent_update"
+
+	// NotApplied — элемент пачки не сохранен, потому что в режиме atomic
+	// отвергнуты другие элементы.
+	NotApplied = "not_applied"
+
+	// IdempotencyInProgress — запрос с тем же ключом Idempotency-Key еще выполняется.
+	IdempotencyInProgress = "idempotency_in_progress"
+	// IdempotencyKeyReused — ключ Idempotency-Key уже использован с другим запросом.
+	IdempotencyKeyReused = "idempotency_key_reused"
+)
+
+// StatusClientClosedRequest — нестандартный статус, которым отмечается
+// запрос, отмененный клиентом (так же, как в nginx). Клиент его не получает,
+// но статус попадает в журналы и отличает отмену от ошибки сервера.
+const StatusClientClosedRequest = 499
+
+// Write отвечает клиенту ошибкой со статусом status.
+func Write(w http.ResponseWriter, r *http.Request, status int, code, message string, details interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.ErrorResponse{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: middleware.RequestIDFromContext(r.Context()),
+	})
+}
@@ -0,0 +1,32 @@
+// Package audit определяет, от чьего имени выполняется изменение данных.
+// Сами записи журнала изменений сохраняют репозитории: PostgreSQL — триггерами
+// на таблицах, хранилище в памяти — при каждой операции записи.
+package audit
+
+import (
+	"context"
+	"goAsu/internal/auth"
+	"goAsu/internal/middleware"
+)
+
+const (
+	Create = "create"
+	Update = "update"
+	Delete = "delete"
+)
+
+// Entities — ресурсы, изменения которых попадают в журнал.
+var Entities = []string{"objects", "wells", "well_day_histories", "well_day_plans"}
+
+// SystemUser — автор изменений, выполненных вне запроса API, например
+// подкомандой import.
+const SystemUser = "system"
+
+// Actor возвращает имя клиента и идентификатор запроса из ctx.
+func Actor(ctx context.Context) (user, requestID string) {
+	user = SystemUser
+	if p, ok := auth.FromContext(ctx); ok {
+		user = p.Name
+	}
+	return user, middleware.RequestIDFromContext(ctx)
+}
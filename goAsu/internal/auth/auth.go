@@ -0,0 +1,235 @@
+// Package auth проверяет подлинность клиентов API и их права по ролям.
+//
+// Клиент предъявляет ключ API в заголовке X-API-Key или JWT в заголовке
+// Authorization: Bearer <token>. Роли токена берутся из утверждения roles,
+// имя клиента — из sub. Чтение доступно любой роли; запись истории — ролям
+// operator и admin, планов — planner и admin, справочников — только admin.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"goAsu/internal/apierror"
+	"goAsu/internal/config"
+	"goAsu/internal/middleware"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	Viewer   = "viewer"
+	Operator = "operator"
+	Planner  = "planner"
+	Admin    = "admin"
+)
+
+// Permission — право на действие с группой ресурсов.
+type Permission int
+
+const (
+	// Read разрешает чтение любых ресурсов и отчетов.
+	Read Permission = iota
+	// WriteHistories разрешает изменять историю скважин за день.
+	WriteHistories
+	// WritePlans разрешает изменять планы скважин за день.
+	WritePlans
+	// WriteDirectories разрешает изменять объекты, типы объектов и скважины.
+	WriteDirectories
+)
+
+var rolePermissions = map[string][]Permission{
+	Viewer:   {Read},
+	Operator: {Read, WriteHistories},
+	Planner:  {Read, WritePlans},
+	Admin:    {Read, WriteHistories, WritePlans, WriteDirectories},
+}
+
+var (
+	errNoCredentials = errors.New("credentials required")
+	errInvalidKey    = errors.New("invalid API key")
+)
+
+// Principal — клиент, подлинность которого подтверждена.
+type Principal struct {
+	Name  string
+	Roles []string
+}
+
+// Can сообщает, дает ли хотя бы одна из ролей клиента право perm.
+func (p Principal) Can(perm Permission) bool {
+	for _, role := range p.Roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal возвращает контекст, содержащий клиента p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext возвращает клиента, сохраненного в контексте запроса.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// anonymous — клиент запросов при отключенной проверке подлинности.
+var anonymous = Principal{Name: "anonymous", Roles: []string{Admin}}
+
+type Authenticator struct {
+	enabled bool
+	keys    map[[sha256.Size]byte]Principal
+	jwtKey  interface{}
+	parser  *jwt.Parser
+}
+
+// New создает Authenticator по конфигурации. Ключи API хранятся в виде
+// хешей SHA-256; файл открытого ключа JWT читается при создании.
+func New(cfg config.AuthConfig) (*Authenticator, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	a := &Authenticator{enabled: cfg.Enabled, keys: map[[sha256.Size]byte]Principal{}}
+	if !cfg.Enabled {
+		return a, nil
+	}
+
+	for _, key := range cfg.APIKeys {
+		if err := checkRoles(key.Roles); err != nil {
+			return nil, fmt.Errorf("auth: api key %s: %w", key.Name, err)
+		}
+		a.keys[sha256.Sum256([]byte(key.Key))] = Principal{Name: key.Name, Roles: key.Roles}
+	}
+
+	var methods []string
+	switch {
+	case cfg.JWT.Secret != "":
+		a.jwtKey = []byte(cfg.JWT.Secret)
+		methods = []string{"HS256", "HS384", "HS512"}
+	case cfg.JWT.PublicKeyFile != "":
+		key, algs, err := loadPublicKey(cfg.JWT.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		a.jwtKey, methods = key, algs
+	}
+	if a.jwtKey != nil {
+		opts := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired()}
+		if cfg.JWT.Issuer != "" {
+			opts = append(opts, jwt.WithIssuer(cfg.JWT.Issuer))
+		}
+		if cfg.JWT.Audience != "" {
+			opts = append(opts, jwt.WithAudience(cfg.JWT.Audience))
+		}
+		a.parser = jwt.NewParser(opts...)
+	}
+	return a, nil
+}
+
+// Authenticate определяет клиента по заголовкам запроса.
+func (a *Authenticator) Authenticate(r *http.Request) (Principal, error) {
+	if !a.enabled {
+		return anonymous, nil
+	}
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return a.apiKey(key)
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") && a.parser != nil {
+		return a.token(strings.TrimSpace(token))
+	}
+	return Principal{}, errNoCredentials
+}
+
+// apiKey ищет ключ по его хешу, поэтому время проверки не зависит
+// от того, сколько символов ключа угадано.
+func (a *Authenticator) apiKey(key string) (Principal, error) {
+	if p, ok := a.keys[sha256.Sum256([]byte(key))]; ok {
+		return p, nil
+	}
+	return Principal{}, errInvalidKey
+}
+
+type claims struct {
+	Roles []string `json:"roles"`
+	jwt.RegisteredClaims
+}
+
+func (a *Authenticator) token(raw string) (Principal, error) {
+	var c claims
+	if _, err := a.parser.ParseWithClaims(raw, &c, func(*jwt.Token) (interface{}, error) {
+		return a.jwtKey, nil
+	}); err != nil {
+		return Principal{}, err
+	}
+	if c.Subject == "" {
+		return Principal{}, errors.New("token has no subject")
+	}
+	return Principal{Name: c.Subject, Roles: c.Roles}, nil
+}
+
+// Protect проверяет подлинность клиента и его права перед вызовом next.
+// Запросы GET, HEAD и OPTIONS требуют права Read, остальные методы — права write.
+// Клиент сохраняется в контексте запроса и доступен через FromContext.
+func (a *Authenticator) Protect(write Permission, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := a.Authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="goAsu"`)
+			apierror.Write(w, r, http.StatusUnauthorized, apierror.Unauthorized, "Unauthorized", nil)
+			return
+		}
+
+		middleware.SetUser(r.Context(), p.Name)
+		need := write
+		switch r.Method {
+		case "GET", "HEAD", "OPTIONS":
+			need = Read
+		}
+		if !p.Can(need) {
+			apierror.Write(w, r, http.StatusForbidden, apierror.Forbidden, "Forbidden", nil)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), p)))
+	})
+}
+
+func checkRoles(roles []string) error {
+	for _, role := range roles {
+		if _, ok := rolePermissions[role]; !ok {
+			return fmt.Errorf("unknown role %q", role)
+		}
+	}
+	return nil
+}
+
+// loadPublicKey читает открытый ключ из файла PEM и возвращает его
+// вместе с допустимыми алгоритмами подписи.
+func loadPublicKey(path string) (interface{}, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("auth: %w", err)
+	}
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return key, []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}, nil
+	}
+	if key, err := jwt.ParseECPublicKeyFromPEM(data); err == nil {
+		return key, []string{"ES256", "ES384", "ES512"}, nil
+	}
+	if key, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		return key, []string{"EdDSA"}, nil
+	}
+	return nil, nil, fmt.Errorf("auth: %s: unsupported public key", path)
+}
@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"goAsu/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const secret = "test-secret"
+
+func newTestAuthenticator(t *testing.T) *Authenticator {
+	t.Helper()
+	a, err := New(config.AuthConfig{
+		Enabled: true,
+		APIKeys: []config.APIKey{
+			{Name: "scada", Key: "operator-key", Roles: []string{Operator}},
+			{Name: "dashboard", Key: "viewer-key", Roles: []string{Viewer}},
+		},
+		JWT: config.JWTConfig{Secret: secret, Issuer: "sso"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// signToken возвращает JWT, подписанный ключом key.
+func signToken(t *testing.T, key string, c claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString([]byte(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + token
+}
+
+func TestAuthenticate(t *testing.T) {
+	valid := func(sub string, roles ...string) claims {
+		return claims{Roles: roles, RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   sub,
+			Issuer:    "sso",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}}
+	}
+	expired := valid("planner", Planner)
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+	noExpiry := valid("planner", Planner)
+	noExpiry.ExpiresAt = nil
+	otherIssuer := valid("planner", Planner)
+	otherIssuer.Issuer = "other"
+
+	tests := []struct {
+		name    string
+		header  string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"api key", "X-API-Key", "operator-key", "scada", false},
+		{"unknown api key", "X-API-Key", "wrong", "", true},
+		{"jwt", "Authorization", signToken(t, secret, valid("planner", Planner)), "planner", false},
+		{"lowercase scheme", "Authorization", "bearer " + signToken(t, secret, valid("planner", Planner))[len("Bearer "):], "planner", false},
+		{"jwt with wrong key", "Authorization", signToken(t, "other-secret", valid("planner", Planner)), "", true},
+		{"expired jwt", "Authorization", signToken(t, secret, expired), "", true},
+		{"jwt without exp", "Authorization", signToken(t, secret, noExpiry), "", true},
+		{"jwt from another issuer", "Authorization", signToken(t, secret, otherIssuer), "", true},
+		{"jwt without subject", "Authorization", signToken(t, secret, valid("", Planner)), "", true},
+		{"basic auth", "Authorization", "Basic dXNlcjpwYXNz", "", true},
+		{"no credentials", "", "", "", true},
+	}
+	a := newTestAuthenticator(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/wells", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			p, err := a.Authenticate(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authenticate() error = %v, want error %v", err, tt.wantErr)
+			}
+			if p.Name != tt.want {
+				t.Errorf("principal = %q, want %q", p.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestCan(t *testing.T) {
+	tests := []struct {
+		roles []string
+		perm  Permission
+		want  bool
+	}{
+		{[]string{Viewer}, Read, true},
+		{[]string{Viewer}, WriteHistories, false},
+		{[]string{Operator}, WriteHistories, true},
+		{[]string{Operator}, WritePlans, false},
+		{[]string{Planner}, WritePlans, true},
+		{[]string{Planner}, WriteDirectories, false},
+		{[]string{Operator, Planner}, WritePlans, true},
+		{[]string{Admin}, WriteDirectories, true},
+		{[]string{"unknown"}, Read, false},
+		{nil, Read, false},
+	}
+	for _, tt := range tests {
+		if got := (Principal{Roles: tt.roles}).Can(tt.perm); got != tt.want {
+			t.Errorf("%v.Can(%d) = %v, want %v", tt.roles, tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestProtect(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		key    string
+		write  Permission
+		status int
+	}{
+		{"viewer reads", "GET", "viewer-key", WriteHistories, http.StatusOK},
+		{"viewer writes", "POST", "viewer-key", WriteHistories, http.StatusForbidden},
+		{"operator writes history", "PUT", "operator-key", WriteHistories, http.StatusOK},
+		{"operator writes plans", "PUT", "operator-key", WritePlans, http.StatusForbidden},
+		{"operator writes directories", "DELETE", "operator-key", WriteDirectories, http.StatusForbidden},
+		{"no credentials", "GET", "", WriteHistories, http.StatusUnauthorized},
+		{"invalid key", "GET", "wrong", WriteHistories, http.StatusUnauthorized},
+	}
+	a := newTestAuthenticator(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var principal Principal
+			h := a.Protect(tt.write, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				principal, _ = FromContext(r.Context())
+			}))
+			r := httptest.NewRequest(tt.method, "/", nil)
+			if tt.key != "" {
+				r.Header.Set("X-API-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("WWW-Authenticate header is missing")
+			}
+			if tt.status == http.StatusOK && principal.Name == "" {
+				t.Error("principal is not stored in the request context")
+			}
+		})
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	a, err := New(config.AuthConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := a.Authenticate(httptest.NewRequest("DELETE", "/wells/1", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "anonymous" || !p.Can(WriteDirectories) {
+		t.Errorf("principal = %+v, want anonymous admin", p)
+	}
+}
+
+func TestNewRejectsUnknownRole(t *testing.T) {
+	_, err := New(config.AuthConfig{
+		Enabled: true,
+		APIKeys: []config.APIKey{{Name: "scada", Key: "key", Roles: []string{"root"}}},
+	})
+	if err == nil {
+		t.Error("New() accepted an unknown role")
+	}
+}
@@ -0,0 +1,146 @@
+// Package civil описывает календарную дату без времени суток и часового
+// пояса — дату, к которой относятся фактические и плановые данные скважины.
+package civil
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// Layout — формат даты ISO 8601, в котором даты принимаются и выводятся.
+const Layout = "2006-01-02"
+
+// Date — календарная дата. Дата не зависит от часового пояса: 2024-12-10
+// остается той же датой на сервере, в базе данных и у клиента. Нулевое
+// значение означает, что дата не задана. Значения можно сравнивать
+// оператором == и использовать как ключи map.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// Of возвращает дату момента t в часовом поясе t.
+func Of(t time.Time) Date {
+	var d Date
+	d.Year, d.Month, d.Day = t.Date()
+	return d
+}
+
+// Today возвращает текущую дату в часовом поясе сервера.
+func Today() Date {
+	return Of(time.Now())
+}
+
+// ParseError сообщает, что строка не является датой в формате YYYY-MM-DD.
+type ParseError struct {
+	Value string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid date %q: use YYYY-MM-DD", e.Value)
+}
+
+// Parse разбирает дату строго в формате YYYY-MM-DD. Ошибка имеет тип *ParseError.
+func Parse(s string) (Date, error) {
+	t, err := time.Parse(Layout, s)
+	if err != nil {
+		return Date{}, &ParseError{Value: s}
+	}
+	return Of(t), nil
+}
+
+// String возвращает дату в формате YYYY-MM-DD или пустую строку
+// для нулевой даты.
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+func (d Date) IsZero() bool {
+	return d == Date{}
+}
+
+// Time возвращает начало дня d в UTC.
+func (d Date) Time() time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, time.UTC)
+}
+
+// AddDays возвращает дату, отстоящую от d на n дней.
+func (d Date) AddDays(n int) Date {
+	return Of(d.Time().AddDate(0, 0, n))
+}
+
+// Compare возвращает -1, 0 или 1, если d раньше e, совпадает с ней или позже.
+func (d Date) Compare(e Date) int {
+	return d.Time().Compare(e.Time())
+}
+
+func (d Date) Before(e Date) bool {
+	return d.Compare(e) < 0
+}
+
+func (d Date) After(e Date) bool {
+	return d.Compare(e) > 0
+}
+
+// MarshalText выводит дату в формате YYYY-MM-DD, нулевую дату — пустой
+// строкой.
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// MarshalJSON выводит дату строкой YYYY-MM-DD, а нулевую дату — null.
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+// UnmarshalText принимает только формат YYYY-MM-DD: время суток и часовой
+// пояс в дате недопустимы. Пустая строка означает нулевую дату, поэтому
+// значения MarshalText читаются обратно без потерь; null в JSON оставляет
+// дату без изменений.
+func (d *Date) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*d = Date{}
+		return nil
+	}
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Scan читает дату из столбца DATE. Драйвер PostgreSQL передает ее как
+// time.Time в UTC, поэтому дата берется без перевода в местный пояс.
+func (d *Date) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case time.Time:
+		*d = Of(v)
+		return nil
+	case string:
+		return d.UnmarshalText([]byte(v))
+	case []byte:
+		return d.UnmarshalText(v)
+	case nil:
+		return fmt.Errorf("civil: cannot scan NULL into Date")
+	}
+	return fmt.Errorf("civil: cannot scan %T into Date", src)
+}
+
+// Value передает дату в базу данных строкой YYYY-MM-DD, чтобы PostgreSQL
+// не переводил ее из часового пояса соединения. Нулевая дата передается
+// как NULL.
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+	return d.String(), nil
+}
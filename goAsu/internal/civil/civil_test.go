@@ -0,0 +1,171 @@
+package civil
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+var dec10 = Date{Year: 2024, Month: time.December, Day: 10}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Date
+		wantErr bool
+	}{
+		{"2024-12-10", dec10, false},
+		{"2024-02-29", Date{Year: 2024, Month: time.February, Day: 29}, false},
+		{"2023-02-29", Date{}, true},
+		{"2024-12-10T00:00:00Z", Date{}, true},
+		{"10.12.2024", Date{}, true},
+		{"2024-12-1", Date{}, true},
+		{"", Date{}, true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Parse(%q) = %v, %v; want %v, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+		var parseErr *ParseError
+		if err != nil && (!errors.As(err, &parseErr) || parseErr.Value != tt.value) {
+			t.Errorf("Parse(%q) error = %#v, want *ParseError", tt.value, err)
+		}
+	}
+}
+
+func TestCompareAndAddDays(t *testing.T) {
+	next := Date{Year: 2025, Month: time.January, Day: 1}
+	dec31 := Date{Year: 2024, Month: time.December, Day: 31}
+	if got := dec31.AddDays(1); got != next {
+		t.Errorf("AddDays(1) = %v, want %v", got, next)
+	}
+	if got := next.AddDays(-22); got != dec10 {
+		t.Errorf("AddDays(-22) = %v, want %v", got, dec10)
+	}
+	if !dec10.Before(next) || !next.After(dec10) || dec10.Compare(dec10) != 0 {
+		t.Error("dates are ordered incorrectly")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type record struct {
+		Date Date `json:"date"`
+	}
+	tests := []struct {
+		name    string
+		input   string
+		initial Date
+		want    Date
+		wantErr bool
+	}{
+		{"date", `{"date":"2024-12-10"}`, Date{}, dec10, false},
+		{"null keeps value", `{"date":null}`, dec10, dec10, false},
+		{"empty string", `{"date":""}`, dec10, Date{}, false},
+		{"timestamp", `{"date":"2024-12-10T00:00:00Z"}`, Date{}, Date{}, true},
+		{"number", `{"date":20241210}`, Date{}, Date{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := record{Date: tt.initial}
+			err := json.Unmarshal([]byte(tt.input), &rec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && rec.Date != tt.want {
+				t.Errorf("date = %v, want %v", rec.Date, tt.want)
+			}
+		})
+	}
+
+	for _, tt := range []struct {
+		date Date
+		want string
+	}{
+		{dec10, `{"date":"2024-12-10"}`},
+		{Date{}, `{"date":null}`},
+	} {
+		data, err := json.Marshal(record{Date: tt.date})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("Marshal(%v) = %s, want %s", tt.date, data, tt.want)
+		}
+		var back record
+		if err := json.Unmarshal(data, &back); err != nil || back.Date != tt.date {
+			t.Errorf("round trip of %v = %v, %v", tt.date, back.Date, err)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	// Нулевая дата в ключах map и текстовых форматах — пустая строка.
+	data, err := json.Marshal(map[Date]int{dec10: 1, {}: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"":0,"2024-12-10":1}`; string(data) != want {
+		t.Errorf("Marshal map = %s, want %s", data, want)
+	}
+	var back map[Date]int
+	if err := json.Unmarshal(data, &back); err != nil || back[dec10] != 1 || len(back) != 2 {
+		t.Errorf("round trip = %v, %v", back, err)
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     interface{}
+		want    Date
+		wantErr bool
+	}{
+		{"utc time", time.Date(2024, time.December, 10, 0, 0, 0, 0, time.UTC), dec10, false},
+		// Дата берется в поясе значения, а не сервера.
+		{"time in another zone", time.Date(2024, time.December, 10, 23, 30, 0, 0, time.FixedZone("UTC+5", 5*3600)), dec10, false},
+		{"string", "2024-12-10", dec10, false},
+		{"bytes", []byte("2024-12-10"), dec10, false},
+		{"invalid string", "10.12.2024", Date{}, true},
+		{"null", nil, Date{}, true},
+		{"number", int64(20241210), Date{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d Date
+			err := d.Scan(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan(%v) error = %v, want error %v", tt.src, err, tt.wantErr)
+			}
+			if d != tt.want {
+				t.Errorf("Scan(%v) = %v, want %v", tt.src, d, tt.want)
+			}
+		})
+	}
+}
+
+func TestValue(t *testing.T) {
+	tests := []struct {
+		date Date
+		want driver.Value
+	}{
+		{dec10, "2024-12-10"},
+		{Date{Year: 1, Month: time.January, Day: 1}, "0001-01-01"},
+		{Date{}, nil},
+	}
+	for _, tt := range tests {
+		got, err := tt.date.Value()
+		if err != nil || got != tt.want {
+			t.Errorf("%#v.Value() = %v, %v; want %v", tt.date, got, err, tt.want)
+		}
+		if tt.want == nil {
+			continue
+		}
+		var back Date
+		if err := back.Scan(got); err != nil || back != tt.date {
+			t.Errorf("round trip of %v = %v, %v", tt.date, back, err)
+		}
+	}
+}
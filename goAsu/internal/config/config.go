@@ -0,0 +1,484 @@
+// Package config загружает настройки сервера из файла YAML, переменных
+// окружения и флагов командной строки.
+//
+// Источники применяются в порядке возрастания приоритета:
+// значения по умолчанию, файл конфигурации, переменные окружения, флаги.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	// Storage — способ хранения данных: postgres или memory
+	// (демонстрационный режим без базы данных).
+	Storage    string           `yaml:"storage"`
+	Database   DatabaseConfig   `yaml:"database"`
+	Server     ServerConfig     `yaml:"server"`
+	Auth       AuthConfig       `yaml:"auth"`
+	Validation ValidationConfig `yaml:"validation"`
+	Tracing    TracingConfig    `yaml:"tracing"`
+}
+
+type DatabaseConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+	// QueryTimeout ограничивает время одного обращения к базе данных,
+	// включая чтение всех строк результата, например "30s".
+	QueryTimeout time.Duration `yaml:"query_timeout"`
+	// MaxOpenConns и MaxIdleConns ограничивают число открытых и простаивающих
+	// соединений пула; 0 в MaxOpenConns снимает ограничение.
+	MaxOpenConns int `yaml:"max_open_conns"`
+	MaxIdleConns int `yaml:"max_idle_conns"`
+	// ConnMaxLifetime и ConnMaxIdleTime задают, через сколько времени после
+	// открытия или простоя соединение закрывается; 0 — без ограничения.
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+	// ConnectTimeout — сколько времени при запуске повторяются попытки
+	// подключиться к недоступной базе данных.
+	ConnectTimeout time.Duration `yaml:"connect_timeout"`
+}
+
+type ServerConfig struct {
+	Addr string `yaml:"addr"`
+	// IdempotencyTTL — сколько хранится ответ на запрос с заголовком
+	// Idempotency-Key, например "24h".
+	IdempotencyTTL time.Duration `yaml:"idempotency_ttl"`
+	// ReadHeaderTimeout, ReadTimeout, WriteTimeout и IdleTimeout ограничивают
+	// чтение заголовков, чтение всего запроса, запись ответа и ожидание
+	// следующего запроса в открытом соединении.
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout — сколько сервер ждет завершения выполняемых запросов
+	// после сигнала остановки.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// MaxBodySize ограничивает размер тела запроса в байтах.
+	MaxBodySize int64 `yaml:"max_body_size"`
+	// TLSCertFile и TLSKeyFile — сертификат и закрытый ключ в формате PEM.
+	// Если они заданы, сервер принимает только HTTPS.
+	TLSCertFile string `yaml:"tls_cert_file"`
+	TLSKeyFile  string `yaml:"tls_key_file"`
+}
+
+// AuthConfig задает проверку подлинности клиентов API. Клиент предъявляет
+// либо ключ API, либо JWT, подписанный ключом из секции jwt.
+type AuthConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// AllowAnonymousAdmin разрешает отключить проверку подлинности при
+	// хранении в PostgreSQL. Без проверки любой клиент получает права admin,
+	// поэтому по умолчанию это допускается только для хранилища в памяти.
+	AllowAnonymousAdmin bool      `yaml:"allow_anonymous_admin"`
+	APIKeys             []APIKey  `yaml:"api_keys"`
+	JWT                 JWTConfig `yaml:"jwt"`
+}
+
+type APIKey struct {
+	// Name идентифицирует владельца ключа в журналах.
+	Name  string   `yaml:"name"`
+	Key   string   `yaml:"key"`
+	Roles []string `yaml:"roles"`
+}
+
+// JWTConfig задает ключ проверки подписи токенов: общий секрет для HS256
+// или файл PEM с открытым ключом RSA, ECDSA или Ed25519.
+type JWTConfig struct {
+	Secret        string `yaml:"secret"`
+	PublicKeyFile string `yaml:"public_key_file"`
+	Issuer        string `yaml:"issuer"`
+	Audience      string `yaml:"audience"`
+}
+
+// Default возвращает конфигурацию со значениями по умолчанию.
+func Default() Config {
+	return Config{
+		Storage: "postgres",
+		Database: DatabaseConfig{
+			Host:            "localhost",
+			Port:            5432,
+			SSLMode:         "disable",
+			QueryTimeout:    30 * time.Second,
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			ConnectTimeout:  time.Minute,
+		},
+		Server: ServerConfig{
+			Addr:              ":8080",
+			IdempotencyTTL:    24 * time.Hour,
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       time.Minute,
+			WriteTimeout:      2 * time.Minute,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   30 * time.Second,
+			MaxBodySize:       32 << 20,
+		},
+		Auth: AuthConfig{
+			Enabled: true,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "goasu",
+			SampleRatio: 1,
+		},
+	}
+}
+
+// Load собирает конфигурацию из всех источников и проверяет ее.
+// Путь к файлу задается флагом -config или переменной GOASU_CONFIG;
+// если он не задан, файл не читается.
+func Load(name string, args []string) (*Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	path := fs.String("config", os.Getenv("GOASU_CONFIG"), "путь к файлу конфигурации YAML")
+	overrides := newFlagOverrides(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *path != "" {
+		if err := cfg.loadFile(*path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	overrides.apply(fs, &cfg)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	setString(&c.Storage, "GOASU_STORAGE")
+	setString(&c.Database.Host, "GOASU_DB_HOST")
+	if err := setInt(&c.Database.Port, "GOASU_DB_PORT"); err != nil {
+		return err
+	}
+	setString(&c.Database.User, "GOASU_DB_USER")
+	setString(&c.Database.Password, "GOASU_DB_PASSWORD")
+	setString(&c.Database.Name, "GOASU_DB_NAME")
+	setString(&c.Database.SSLMode, "GOASU_DB_SSLMODE")
+	if err := setDuration(&c.Database.QueryTimeout, "GOASU_DB_QUERY_TIMEOUT"); err != nil {
+		return err
+	}
+	if err := setInt(&c.Database.MaxOpenConns, "GOASU_DB_MAX_OPEN_CONNS"); err != nil {
+		return err
+	}
+	if err := setInt(&c.Database.MaxIdleConns, "GOASU_DB_MAX_IDLE_CONNS"); err != nil {
+		return err
+	}
+	if err := setDuration(&c.Database.ConnectTimeout, "GOASU_DB_CONNECT_TIMEOUT"); err != nil {
+		return err
+	}
+	setString(&c.Server.Addr, "GOASU_ADDR")
+	if err := setDuration(&c.Server.IdempotencyTTL, "GOASU_IDEMPOTENCY_TTL"); err != nil {
+		return err
+	}
+	if err := setDuration(&c.Server.ShutdownTimeout, "GOASU_SHUTDOWN_TIMEOUT"); err != nil {
+		return err
+	}
+	setString(&c.Server.TLSCertFile, "GOASU_TLS_CERT_FILE")
+	setString(&c.Server.TLSKeyFile, "GOASU_TLS_KEY_FILE")
+	if err := setBool(&c.Auth.Enabled, "GOASU_AUTH_ENABLED"); err != nil {
+		return err
+	}
+	if err := setBool(&c.Auth.AllowAnonymousAdmin, "GOASU_AUTH_ALLOW_ANONYMOUS_ADMIN"); err != nil {
+		return err
+	}
+	setString(&c.Auth.JWT.Secret, "GOASU_JWT_SECRET")
+	setString(&c.Auth.JWT.PublicKeyFile, "GOASU_JWT_PUBLIC_KEY_FILE")
+	setString(&c.Tracing.Exporter, "GOASU_TRACING_EXPORTER")
+	setString(&c.Tracing.Endpoint, "GOASU_TRACING_ENDPOINT")
+	return nil
+}
+
+// Validate проверяет, что обязательные параметры заданы и корректны.
+func (c *Config) Validate() error {
+	var problems []string
+	switch c.Storage {
+	case "postgres":
+		problems = append(problems, c.Database.validate()...)
+	case "memory":
+	default:
+		problems = append(problems, "storage must be postgres or memory")
+	}
+	if c.Server.Addr == "" {
+		problems = append(problems, "server.addr is required")
+	}
+	problems = append(problems, c.Server.validate()...)
+	problems = append(problems, c.Validation.validate()...)
+	problems = append(problems, c.Tracing.validate()...)
+	if len(problems) > 0 {
+		return fmt.Errorf("config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (s ServerConfig) validate() []string {
+	var problems []string
+	if s.IdempotencyTTL <= 0 {
+		problems = append(problems, "server.idempotency_ttl must be positive")
+	}
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"read_header_timeout", s.ReadHeaderTimeout},
+		{"read_timeout", s.ReadTimeout},
+		{"write_timeout", s.WriteTimeout},
+		{"idle_timeout", s.IdleTimeout},
+		{"shutdown_timeout", s.ShutdownTimeout},
+	} {
+		if timeout.value <= 0 {
+			problems = append(problems, "server."+timeout.name+" must be positive")
+		}
+	}
+	if s.MaxBodySize <= 0 {
+		problems = append(problems, "server.max_body_size must be positive")
+	}
+	if (s.TLSCertFile == "") != (s.TLSKeyFile == "") {
+		problems = append(problems, "server.tls_cert_file and server.tls_key_file must be set together")
+	}
+	return problems
+}
+
+func (d DatabaseConfig) validate() []string {
+	var problems []string
+	if d.Host == "" {
+		problems = append(problems, "database.host is required")
+	}
+	if d.Port < 1 || d.Port > 65535 {
+		problems = append(problems, "database.port must be between 1 and 65535")
+	}
+	if d.User == "" {
+		problems = append(problems, "database.user is required")
+	}
+	if d.Name == "" {
+		problems = append(problems, "database.name is required")
+	}
+	switch d.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		problems = append(problems, "database.sslmode is invalid")
+	}
+	if d.QueryTimeout <= 0 {
+		problems = append(problems, "database.query_timeout must be positive")
+	}
+	if d.MaxOpenConns < 0 || d.MaxIdleConns < 0 {
+		problems = append(problems, "database.max_open_conns and database.max_idle_conns must not be negative")
+	}
+	if d.MaxOpenConns > 0 && d.MaxIdleConns > d.MaxOpenConns {
+		problems = append(problems, "database.max_idle_conns must not exceed database.max_open_conns")
+	}
+	if d.ConnMaxLifetime < 0 || d.ConnMaxIdleTime < 0 {
+		problems = append(problems, "database.conn_max_lifetime and database.conn_max_idle_time must not be negative")
+	}
+	if d.ConnectTimeout <= 0 {
+		problems = append(problems, "database.connect_timeout must be positive")
+	}
+	return problems
+}
+
+// ValidateAuth проверяет параметры проверки подлинности с учетом способа
+// хранения: отключить проверку для PostgreSQL можно только явно, параметром
+// auth.allow_anonymous_admin. Выполняется только при запуске сервера.
+func (c *Config) ValidateAuth() error {
+	if !c.Auth.Enabled && c.Storage != "memory" && !c.Auth.AllowAnonymousAdmin {
+		return errors.New("config: auth is disabled, so every client would get admin rights; set auth.allow_anonymous_admin to true to allow this with storage " + c.Storage)
+	}
+	return c.Auth.Validate()
+}
+
+// Validate проверяет параметры проверки подлинности. Она выполняется
+// только при запуске сервера: подкомандам migrate и import ключи не нужны.
+func (a AuthConfig) Validate() error {
+	if !a.Enabled {
+		return nil
+	}
+	var problems []string
+	if len(a.APIKeys) == 0 && a.JWT.Secret == "" && a.JWT.PublicKeyFile == "" {
+		problems = append(problems, "auth requires api_keys or a jwt key (set auth.enabled to false to disable authentication)")
+	}
+	for i, key := range a.APIKeys {
+		if key.Name == "" || key.Key == "" {
+			problems = append(problems, fmt.Sprintf("auth.api_keys[%d]: name and key are required", i))
+		}
+		if len(key.Roles) == 0 {
+			problems = append(problems, fmt.Sprintf("auth.api_keys[%d]: at least one role is required", i))
+		}
+	}
+	if a.JWT.Secret != "" && a.JWT.PublicKeyFile != "" {
+		problems = append(problems, "auth.jwt: secret and public_key_file are mutually exclusive")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// TracingConfig задает экспорт трассировки OpenTelemetry. Exporter — none
+// (трассировка выключена), stdout (spans выводятся в stdout для отладки)
+// или otlp (spans отправляются по OTLP/HTTP на Endpoint, например
+// "localhost:4318"; пустой Endpoint берется из OTEL_EXPORTER_OTLP_ENDPOINT).
+// SampleRatio — доля трассируемых запросов от 0 до 1; запросы с входящим
+// заголовком traceparent трассируются по решению вызывающей стороны.
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	ServiceName string  `yaml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+func (t TracingConfig) validate() []string {
+	var problems []string
+	switch t.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		problems = append(problems, "tracing.exporter must be none, stdout or otlp")
+	}
+	if t.ServiceName == "" {
+		problems = append(problems, "tracing.service_name is required")
+	}
+	if t.SampleRatio < 0 || t.SampleRatio > 1 {
+		problems = append(problems, "tracing.sample_ratio must be between 0 and 1")
+	}
+	return problems
+}
+
+// ValidationConfig задает допустимые диапазоны показателей истории и планов
+// в дополнение к встроенным физическим ограничениям.
+type ValidationConfig struct {
+	Rules []RangeRule `yaml:"rules"`
+}
+
+// RangeRule ограничивает значение показателя Field (debit, ee_consume,
+// expenses или pump_operating). Правило может относиться ко всем скважинам,
+// к одной скважине (Well) или к месторождению (Mest), а также только к факту
+// или только к плану (Source). Для каждого показателя применяется наиболее
+// конкретное правило: скважины, затем месторождения, затем общее.
+type RangeRule struct {
+	Field  string   `yaml:"field"`
+	Source string   `yaml:"source"`
+	Well   int      `yaml:"well"`
+	Mest   int      `yaml:"mest"`
+	Min    *float64 `yaml:"min"`
+	Max    *float64 `yaml:"max"`
+}
+
+func (v ValidationConfig) validate() []string {
+	var problems []string
+	for i, rule := range v.Rules {
+		switch rule.Field {
+		case "debit", "ee_consume", "expenses", "pump_operating":
+		default:
+			problems = append(problems, fmt.Sprintf("validation.rules[%d]: field must be debit, ee_consume, expenses or pump_operating", i))
+		}
+		switch rule.Source {
+		case "", "fact", "plan":
+		default:
+			problems = append(problems, fmt.Sprintf("validation.rules[%d]: source must be fact or plan", i))
+		}
+		if rule.Well != 0 && rule.Mest != 0 {
+			problems = append(problems, fmt.Sprintf("validation.rules[%d]: well and mest are mutually exclusive", i))
+		}
+		if rule.Min == nil && rule.Max == nil {
+			problems = append(problems, fmt.Sprintf("validation.rules[%d]: min or max is required", i))
+		}
+		if rule.Min != nil && rule.Max != nil && *rule.Min > *rule.Max {
+			problems = append(problems, fmt.Sprintf("validation.rules[%d]: min is greater than max", i))
+		}
+	}
+	return problems
+}
+
+// DSN возвращает строку подключения к PostgreSQL в формате key=value.
+func (d DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		quote(d.Host), d.Port, quote(d.User), quote(d.Password), quote(d.Name), quote(d.SSLMode))
+}
+
+func quote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+func setString(dst *string, key string) {
+	if value, ok := os.LookupEnv(key); ok {
+		*dst = value
+	}
+}
+
+func setBool(dst *bool, key string) error {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", key, err)
+	}
+	*dst = b
+	return nil
+}
+
+func setInt(dst *int, key string) error {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", key, err)
+	}
+	*dst = n
+	return nil
+}
+
+func setDuration(dst *time.Duration, key string) error {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", key, err)
+	}
+	*dst = d
+	return nil
+}
@@ -0,0 +1,30 @@
+package config
+
+import "testing"
+
+func TestValidateAuth(t *testing.T) {
+	tests := []struct {
+		name    string
+		storage string
+		auth    AuthConfig
+		wantErr bool
+	}{
+		{"disabled with memory storage", "memory", AuthConfig{}, false},
+		{"disabled with postgres", "postgres", AuthConfig{}, true},
+		{"disabled with postgres and anonymous admin allowed", "postgres", AuthConfig{AllowAnonymousAdmin: true}, false},
+		{"enabled without keys", "postgres", AuthConfig{Enabled: true}, true},
+		{"enabled with api key", "postgres", AuthConfig{Enabled: true, APIKeys: []APIKey{{Name: "scada", Key: "key", Roles: []string{"operator"}}}}, false},
+		{"api key without roles", "memory", AuthConfig{Enabled: true, APIKeys: []APIKey{{Name: "scada", Key: "key"}}}, true},
+		{"two jwt keys", "memory", AuthConfig{Enabled: true, JWT: JWTConfig{Secret: "s", PublicKeyFile: "key.pem"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Storage = tt.storage
+			cfg.Auth = tt.auth
+			if err := cfg.ValidateAuth(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAuth() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
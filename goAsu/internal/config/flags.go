@@ -0,0 +1,56 @@
+package config
+
+import "flag"
+
+// flagOverrides хранит значения флагов командной строки. Флаги применяются
+// поверх файла и окружения, только если они явно указаны.
+type flagOverrides struct {
+	storage    string
+	dbHost     string
+	dbPort     int
+	dbUser     string
+	dbPassword string
+	dbName     string
+	dbSSLMode  string
+	addr       string
+	auth       bool
+}
+
+func newFlagOverrides(fs *flag.FlagSet) *flagOverrides {
+	o := &flagOverrides{}
+	fs.StringVar(&o.storage, "storage", "", "способ хранения данных: postgres или memory")
+	fs.StringVar(&o.dbHost, "db-host", "", "адрес сервера PostgreSQL")
+	fs.IntVar(&o.dbPort, "db-port", 0, "порт сервера PostgreSQL")
+	fs.StringVar(&o.dbUser, "db-user", "", "имя пользователя PostgreSQL")
+	fs.StringVar(&o.dbPassword, "db-password", "", "пароль PostgreSQL (лучше задавать через GOASU_DB_PASSWORD)")
+	fs.StringVar(&o.dbName, "db-name", "", "имя базы данных")
+	fs.StringVar(&o.dbSSLMode, "db-sslmode", "", "режим SSL подключения к PostgreSQL")
+	fs.StringVar(&o.addr, "addr", "", "адрес, на котором слушает HTTP-сервер")
+	fs.BoolVar(&o.auth, "auth", true, "проверять подлинность клиентов API (-auth=false отключает проверку)")
+	return o
+}
+
+func (o *flagOverrides) apply(fs *flag.FlagSet, cfg *Config) {
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "storage":
+			cfg.Storage = o.storage
+		case "db-host":
+			cfg.Database.Host = o.dbHost
+		case "db-port":
+			cfg.Database.Port = o.dbPort
+		case "db-user":
+			cfg.Database.User = o.dbUser
+		case "db-password":
+			cfg.Database.Password = o.dbPassword
+		case "db-name":
+			cfg.Database.Name = o.dbName
+		case "db-sslmode":
+			cfg.Database.SSLMode = o.dbSSLMode
+		case "addr":
+			cfg.Server.Addr = o.addr
+		case "auth":
+			cfg.Auth.Enabled = o.auth
+		}
+	})
+}
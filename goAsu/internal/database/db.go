@@ -1,25 +1,79 @@
-package database
-
-import (
-	"database/sql"
-	"fmt"
-	"log"
-)
-
-func InitDB(host string, port int, user, password, dbname string) *sql.DB {
-	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname)
-
-	db, err := sql.Open("postgres", psqlInfo)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	err = db.Ping()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	fmt.Println("Successfully connected!")
-	return db
-}
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"goAsu/internal/config"
+	"goAsu/internal/migrations"
+	"goAsu/internal/models"
+	"log"
+	"time"
+)
+
+// Задержка между попытками подключения растет от minBackoff до maxBackoff.
+const (
+	minBackoff = 500 * time.Millisecond
+	maxBackoff = 10 * time.Second
+)
+
+// Connect открывает пул соединений с параметрами из cfg и ждет, пока база
+// данных станет доступна: неудачные попытки повторяются с растущей задержкой
+// в течение cfg.ConnectTimeout.
+func Connect(ctx context.Context, cfg config.DatabaseConfig) (*sql.DB, error) {
+	db, err := sql.Open("postgres", cfg.DSN())
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+	defer cancel()
+	for backoff := minBackoff; ; backoff = min(backoff*2, maxBackoff) {
+		err = db.PingContext(ctx)
+		if err == nil {
+			log.Println("Successfully connected!")
+			return db, nil
+		}
+		log.Printf("Database is not available, retrying in %s: %v", backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			db.Close()
+			return nil, fmt.Errorf("database: %w", err)
+		}
+	}
+}
+
+// Check проверяет, что база данных отвечает и схема обновлена до последней
+// встроенной миграции. Результат доступен без аутентификации, поэтому
+// текст ошибки базы данных только записывается в журнал. Если база данных
+// отвечает, но версию схемы прочитать не удалось, сервис не готов, а версия
+// примененной миграции не указывается.
+func Check(ctx context.Context, db *sql.DB) models.Readiness {
+	ready := models.Readiness{Status: models.Ready, Storage: "postgres"}
+	if err := db.PingContext(ctx); err != nil {
+		log.Printf("Readiness check: database is not available: %v", err)
+		ready.Status, ready.Database = models.NotReady, models.DatabaseUnavailable
+		return ready
+	}
+	ready.Database = models.DatabaseOK
+
+	applied, latest, err := migrations.Version(ctx, db)
+	if err != nil {
+		log.Printf("Readiness check: reading migration version: %v", err)
+		ready.Status = models.NotReady
+		if latest > 0 {
+			ready.ExpectedMigrationVersion = &latest
+		}
+		return ready
+	}
+	ready.MigrationVersion, ready.ExpectedMigrationVersion = &applied, &latest
+	if applied < latest {
+		ready.Status = models.NotReady
+	}
+	return ready
+}
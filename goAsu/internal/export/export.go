@@ -0,0 +1,256 @@
+// Package export записывает коллекции моделей в форматах CSV, XLSX и NDJSON.
+//
+// Заголовки столбцов совпадают с JSON-тегами полей модели; поля вложенных
+// структур разворачиваются в столбцы вида debit.plan, кроме типов с текстовым
+// представлением (например, даты), которые занимают один столбец. Записи передаются
+// кодировщику по одной, поэтому выгрузка не требует держать всю выборку в памяти.
+package export
+
+import (
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	CSV    = "csv"
+	XLSX   = "xlsx"
+	NDJSON = "ndjson"
+)
+
+var contentTypes = map[string]string{
+	CSV:    "text/csv; charset=utf-8",
+	XLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	NDJSON: "application/x-ndjson",
+}
+
+var mediaTypes = map[string]string{
+	"text/csv":             CSV,
+	"application/csv":      CSV,
+	"application/x-ndjson": NDJSON,
+	"application/jsonl":    NDJSON,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": XLSX,
+}
+
+// ContentType возвращает значение заголовка Content-Type для формата.
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// FormatFromName возвращает формат по его имени в параметре запроса.
+func FormatFromName(name string) (string, bool) {
+	name = strings.ToLower(name)
+	if name == "jsonl" {
+		name = NDJSON
+	}
+	_, ok := contentTypes[name]
+	return name, ok
+}
+
+// FormatFromAccept выбирает формат выгрузки по заголовку Accept с учетом
+// весов q. Возвращает false, если предпочтительный тип не относится
+// к форматам выгрузки (например, application/json или */*).
+func FormatFromAccept(accept string) (string, bool) {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+		format, ok := mediaTypes[mediaType]
+		if !ok && mediaType != "application/json" && mediaType != "*/*" && mediaType != "application/*" {
+			continue
+		}
+		best, bestQ = format, q
+	}
+	return best, best != ""
+}
+
+// Encoder записывает значения типа T в выбранном формате.
+// После последней записи необходимо вызвать Close.
+type Encoder[T any] struct {
+	format  string
+	w       io.Writer
+	columns []column
+	csv     *csv.Writer
+	json    *json.Encoder
+	book    *excelize.File
+	sheet   *excelize.StreamWriter
+	row     int
+}
+
+// NewEncoder создает кодировщик и записывает заголовок с именами столбцов.
+func NewEncoder[T any](w io.Writer, format string) (*Encoder[T], error) {
+	e := &Encoder[T]{format: format, w: w, columns: columns(reflect.TypeOf((*T)(nil)).Elem())}
+	switch format {
+	case CSV:
+		e.csv = csv.NewWriter(w)
+		return e, e.csv.Write(e.names())
+	case NDJSON:
+		e.json = json.NewEncoder(w)
+		return e, nil
+	case XLSX:
+		e.book = excelize.NewFile()
+		sheet, err := e.book.NewStreamWriter(e.book.GetSheetName(0))
+		if err != nil {
+			return nil, err
+		}
+		e.sheet = sheet
+		header := make([]interface{}, len(e.columns))
+		for i, name := range e.names() {
+			header[i] = name
+		}
+		return e, e.writeRow(header)
+	}
+	return nil, fmt.Errorf("export: unsupported format %q", format)
+}
+
+// Encode записывает одну запись.
+func (e *Encoder[T]) Encode(v T) error {
+	switch e.format {
+	case NDJSON:
+		return e.json.Encode(v)
+	case CSV:
+		record := make([]string, len(e.columns))
+		for i, c := range e.columns {
+			record[i] = formatValue(c.value(reflect.ValueOf(v)))
+		}
+		return e.csv.Write(record)
+	default:
+		row := make([]interface{}, len(e.columns))
+		for i, c := range e.columns {
+			row[i] = c.value(reflect.ValueOf(v))
+		}
+		return e.writeRow(row)
+	}
+}
+
+// Close дописывает буферизованные данные. Файл XLSX передается в w целиком
+// только при закрытии; до этого строки накапливаются во временном файле.
+func (e *Encoder[T]) Close() error {
+	switch e.format {
+	case CSV:
+		e.csv.Flush()
+		return e.csv.Error()
+	case XLSX:
+		defer e.book.Close()
+		if err := e.sheet.Flush(); err != nil {
+			return err
+		}
+		_, err := e.book.WriteTo(e.w)
+		return err
+	}
+	return nil
+}
+
+func (e *Encoder[T]) writeRow(row []interface{}) error {
+	e.row++
+	cell, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+	return e.sheet.SetRow(cell, row)
+}
+
+func (e *Encoder[T]) names() []string {
+	names := make([]string, len(e.columns))
+	for i, c := range e.columns {
+		names[i] = c.name
+	}
+	return names
+}
+
+// column — столбец выгрузки: имя и путь к полю модели.
+type column struct {
+	name  string
+	index []int
+}
+
+// value возвращает значение поля или nil, если на пути к нему пустой указатель.
+// Значения с текстовым представлением возвращаются строкой.
+func (c column) value(v reflect.Value) interface{} {
+	for _, i := range c.index {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return nil
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		if err != nil {
+			return nil
+		}
+		return string(text)
+	}
+	return v.Interface()
+}
+
+var textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// columns перечисляет поля структуры t в порядке объявления.
+func columns(t reflect.Type) []column {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var result []column
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && !ft.Implements(textMarshaler) {
+			for _, nested := range columns(ft) {
+				result = append(result, column{name: name + "." + nested.name, index: append([]int{i}, nested.index...)})
+			}
+			continue
+		}
+		result = append(result, column{name: name, index: []int{i}})
+	}
+	return result
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"goAsu/internal/query"
+	"goAsu/internal/repository"
+	"net/http"
+)
+
+var auditQuery = query.Spec{
+	Filters: []query.FilterSpec{
+		{Param: "entity", Field: "entity", Op: query.Eq, Kind: query.String},
+		{Param: "entity_id", Field: "entity_id", Op: query.Eq, Kind: query.String},
+		{Param: "well", Field: "well", Op: query.Eq, Kind: query.Int},
+		{Param: "date", Field: "date", Op: query.Eq, Kind: query.Date},
+		{Param: "action", Field: "action", Op: query.Eq, Kind: query.String},
+		{Param: "user", Field: "user_name", Op: query.Eq, Kind: query.String},
+		{Param: "request_id", Field: "request_id", Op: query.Eq, Kind: query.String},
+	},
+	Sortable: []string{"id", "changed_at"},
+	Key:      []string{"id"},
+}
+
+func AuditHandler(audit repository.AuditRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			getAuditLog(audit, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+// getAuditLog возвращает журнал изменений объектов, скважин, истории и планов.
+// @Summary Журнал изменений
+// @Description Возвращает записи о создании, изменении и удалении объектов, скважин, истории и планов
+// @Description с прежним и новым значением, автором изменения и идентификатором запроса.
+// @Description Например, история изменений факта скважины за день: entity=well_day_histories&well=4455&date=2024-12-01.
+// @Tags audit
+// @Produce json
+// @Param entity query []string false "Ресурс: objects, wells, well_day_histories, well_day_plans" collectionFormat(csv)
+// @Param entity_id query []string false "Ключ записи: id объекта, номер скважины или скважина/дата" collectionFormat(csv)
+// @Param well query []int false "Фильтр по ID скважины" collectionFormat(csv)
+// @Param date query string false "Дата истории или плана (YYYY-MM-DD)" Format(date)
+// @Param action query []string false "Действие: create, update, delete" collectionFormat(csv)
+// @Param user query []string false "Автор изменения" collectionFormat(csv)
+// @Param request_id query string false "Идентификатор запроса (X-Request-ID)"
+// @Param sort query string false "Сортировка: id, changed_at; префикс - означает убывание"
+// @Param limit query int false "Максимальное число записей (по умолчанию 1000, не более 10000)"
+// @Param offset query int false "Число пропускаемых записей"
+// @Success 200 {array} models.AuditEntry
+// @Header 200 {integer} X-Total-Count "Общее число записей, удовлетворяющих фильтрам"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /audit [get]
+func getAuditLog(audit repository.AuditRepository, w http.ResponseWriter, r *http.Request) {
+	list, err := query.Parse(auditQuery, r.URL.Query())
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	entries, total, err := audit.List(r.Context(), list)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	setTotalCount(w, total)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,462 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goAsu/internal/apierror"
+	"goAsu/internal/civil"
+	"goAsu/internal/metrics"
+	"goAsu/internal/models"
+	"goAsu/internal/repository"
+	"goAsu/internal/validation"
+	"net/http"
+)
+
+// maxBatchItems ограничивает число элементов в пачке.
+const maxBatchItems = 10000
+
+// dayKey — ключ дневной записи: скважина и дата.
+type dayKey struct {
+	well int
+	date civil.Date
+}
+
+// itemError — ошибка элемента пачки, найденная до записи.
+type itemError struct {
+	status int
+	resp   models.ErrorResponse
+}
+
+func WellDayHistoriesBatchHandler(histories repository.WellDayHistoryRepository, validator *validation.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			batchWellDayHistories(histories, validator, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+func WellDayPlansBatchHandler(plans repository.WellDayPlanRepository, validator *validation.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			batchWellDayPlans(plans, validator, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+func WellsBatchHandler(wells repository.WellRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			batchWells(wells, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+func ObjectsBatchHandler(objects repository.ObjectRepository, objectTypes repository.ObjectTypeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			batchObjects(objects, objectTypes, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+// batchWellDayHistories сохраняет пачку записей истории дневных данных.
+// @Summary Пакетная запись истории дневных данных
+// @Description Принимает массив записей (до 10000) и сохраняет их в одной транзакции; записи с существующим ключом (well, date_fact) заменяются.
+// @Description Каждая запись проверяется правилами валидации; записи с одинаковым ключом в одной пачке не допускаются.
+// @Description В режиме atomic (по умолчанию) при ошибке любой записи не сохраняется ничего и возвращается 422; в режиме best_effort сохраняются все записи без ошибок, а при наличии ошибок возвращается 207.
+// @Description Ответ содержит результат по каждой записи.
+// @Description Требуется роль operator или admin.
+// @Tags well_day_histories
+// @Accept json
+// @Produce json
+// @Param mode query string false "Режим записи" Enums(atomic, best_effort)
+// @Param body body []models.WellDayHistory true "Записи истории дневных данных"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ"
+// @Success 200 {object} models.BatchResult
+// @Success 207 {object} models.BatchResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 422 {object} models.BatchResult
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /well_day_histories/batch [post]
+func batchWellDayHistories(histories repository.WellDayHistoryRepository, validator *validation.Validator, w http.ResponseWriter, r *http.Request) {
+	mode, ok := batchMode(w, r)
+	if !ok {
+		return
+	}
+	items, decodeErrs, invalid, ok := decodeBatch[models.WellDayHistory](w, r, "date_fact")
+	if !ok {
+		return
+	}
+
+	fieldErrs, err := validator.Histories(r.Context(), items)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+	validationErrors(invalid, decodeErrs, fieldErrs)
+	markDuplicates(invalid, func(i int) dayKey { return dayKey{items[i].Well, items[i].DateFact} })
+
+	result, err := saveBatch(mode, invalid, func(idx []int) ([]error, error) {
+		return histories.UpsertBatch(r.Context(), pick(items, idx), mode == models.BatchAtomic)
+	}, nil)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+	metrics.RecordsWritten(metrics.Histories, metrics.SourceAPI, result.Succeeded)
+	writeBatchResult(w, result)
+}
+
+// batchWellDayPlans сохраняет пачку плановых записей.
+// @Summary Пакетная запись плановых данных
+// @Description Принимает массив записей (до 10000) и сохраняет их в одной транзакции; записи с существующим ключом (well, date_plan) заменяются.
+// @Description Каждая запись проверяется правилами валидации; записи с одинаковым ключом в одной пачке не допускаются.
+// @Description В режиме atomic (по умолчанию) при ошибке любой записи не сохраняется ничего и возвращается 422; в режиме best_effort сохраняются все записи без ошибок, а при наличии ошибок возвращается 207.
+// @Description Ответ содержит результат по каждой записи.
+// @Description Требуется роль planner или admin.
+// @Tags well_day_plans
+// @Accept json
+// @Produce json
+// @Param mode query string false "Режим записи" Enums(atomic, best_effort)
+// @Param body body []models.WellDayPlan true "Плановые записи"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ"
+// @Success 200 {object} models.BatchResult
+// @Success 207 {object} models.BatchResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 422 {object} models.BatchResult
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /well_day_plans/batch [post]
+func batchWellDayPlans(plans repository.WellDayPlanRepository, validator *validation.Validator, w http.ResponseWriter, r *http.Request) {
+	mode, ok := batchMode(w, r)
+	if !ok {
+		return
+	}
+	items, decodeErrs, invalid, ok := decodeBatch[models.WellDayPlan](w, r, "date_plan")
+	if !ok {
+		return
+	}
+
+	fieldErrs, err := validator.Plans(r.Context(), items)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+	validationErrors(invalid, decodeErrs, fieldErrs)
+	markDuplicates(invalid, func(i int) dayKey { return dayKey{items[i].Well, items[i].DatePlan} })
+
+	result, err := saveBatch(mode, invalid, func(idx []int) ([]error, error) {
+		return plans.UpsertBatch(r.Context(), pick(items, idx), mode == models.BatchAtomic)
+	}, nil)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+	metrics.RecordsWritten(metrics.Plans, metrics.SourceAPI, result.Succeeded)
+	writeBatchResult(w, result)
+}
+
+// batchWells сохраняет пачку скважин.
+// @Summary Пакетная запись скважин
+// @Description Принимает массив скважин (до 10000) и сохраняет их в одной транзакции; скважины с существующим номером заменяются.
+// @Description Скважины с одинаковым номером в одной пачке не допускаются.
+// @Description В режиме atomic (по умолчанию) при ошибке любой скважины не сохраняется ничего и возвращается 422; в режиме best_effort сохраняются все скважины без ошибок, а при наличии ошибок возвращается 207.
+// @Description Ответ содержит результат по каждой скважине.
+// @Description Требуется роль admin.
+// @Tags wells
+// @Accept json
+// @Produce json
+// @Param mode query string false "Режим записи" Enums(atomic, best_effort)
+// @Param body body []models.Well true "Скважины"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ"
+// @Success 200 {object} models.BatchResult
+// @Success 207 {object} models.BatchResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 422 {object} models.BatchResult
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /wells/batch [post]
+func batchWells(wells repository.WellRepository, w http.ResponseWriter, r *http.Request) {
+	mode, ok := batchMode(w, r)
+	if !ok {
+		return
+	}
+	items, _, invalid, ok := decodeBatch[models.Well](w, r, "")
+	if !ok {
+		return
+	}
+
+	markDuplicates(invalid, func(i int) int { return items[i].Well })
+
+	result, err := saveBatch(mode, invalid, func(idx []int) ([]error, error) {
+		return wells.UpsertBatch(r.Context(), pick(items, idx), mode == models.BatchAtomic)
+	}, nil)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+	writeBatchResult(w, result)
+}
+
+// batchObjects сохраняет пачку объектов.
+// @Summary Пакетная запись объектов
+// @Description Принимает массив объектов (до 10000) и сохраняет их в одной транзакции: объекты без id создаются, объекты с id изменяются.
+// @Description Тип объекта должен существовать в справочнике object_types; объекты с одинаковым id в одной пачке не допускаются.
+// @Description В режиме atomic (по умолчанию) при ошибке любого объекта не сохраняется ничего и возвращается 422; в режиме best_effort сохраняются все объекты без ошибок, а при наличии ошибок возвращается 207.
+// @Description Ответ содержит результат по каждому объекту, для сохраненных — его id.
+// @Description Требуется роль admin.
+// @Tags objects
+// @Accept json
+// @Produce json
+// @Param mode query string false "Режим записи" Enums(atomic, best_effort)
+// @Param body body []models.Object true "Объекты"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ"
+// @Success 200 {object} models.BatchResult
+// @Success 207 {object} models.BatchResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 422 {object} models.BatchResult
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /objects/batch [post]
+func batchObjects(objects repository.ObjectRepository, objectTypes repository.ObjectTypeRepository, w http.ResponseWriter, r *http.Request) {
+	mode, ok := batchMode(w, r)
+	if !ok {
+		return
+	}
+	items, _, invalid, ok := decodeBatch[models.Object](w, r, "")
+	if !ok {
+		return
+	}
+
+	known := map[int]bool{}
+	for i, obj := range items {
+		if invalid[i] != nil {
+			continue
+		}
+		exists, checked := known[obj.Type]
+		if !checked {
+			_, err := objectTypes.Get(r.Context(), obj.Type)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				storeError(w, r, err)
+				return
+			}
+			exists = err == nil
+			known[obj.Type] = exists
+		}
+		if !exists {
+			invalid[i] = &itemError{http.StatusUnprocessableEntity, models.ErrorResponse{Code: apierror.InvalidReference, Message: "Unknown object type"}}
+		}
+	}
+	// Новые объекты без id не могут совпадать друг с другом.
+	markDuplicates(invalid, func(i int) [2]int {
+		if items[i].ID == 0 {
+			return [2]int{0, i}
+		}
+		return [2]int{items[i].ID, -1}
+	})
+
+	var saved []models.Object
+	result, err := saveBatch(mode, invalid, func(idx []int) ([]error, error) {
+		saved = pick(items, idx)
+		return objects.SaveBatch(r.Context(), saved, mode == models.BatchAtomic)
+	}, func(k int) int { return saved[k].ID })
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+	writeBatchResult(w, result)
+}
+
+// batchMode читает режим записи пачки из параметра mode.
+func batchMode(w http.ResponseWriter, r *http.Request) (string, bool) {
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "", models.BatchAtomic:
+		return models.BatchAtomic, true
+	case models.BatchBestEffort:
+		return mode, true
+	default:
+		badRequest(w, r, "Invalid mode: use atomic or best_effort")
+		return "", false
+	}
+}
+
+// decodeBatch читает из тела запроса непустой массив элементов. Элементы
+// разбираются по отдельности, чтобы ошибка в одном не лишала остальные
+// результата: элемент, который не удалось разобрать, отмечается в invalid
+// ошибкой 400, а дата dateField в неверном формате возвращается в decodeErrs
+// ошибкой поля (см. decodeRecord).
+func decodeBatch[T any](w http.ResponseWriter, r *http.Request, dateField string) (items []T, decodeErrs [][]models.FieldError, invalid []*itemError, ok bool) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		bodyError(w, r, err)
+		return nil, nil, nil, false
+	}
+	if len(raw) == 0 {
+		badRequest(w, r, "Batch is empty")
+		return nil, nil, nil, false
+	}
+	if len(raw) > maxBatchItems {
+		apierror.Write(w, r, http.StatusRequestEntityTooLarge, apierror.PayloadTooLarge, fmt.Sprintf("Batch is too large: at most %d items", maxBatchItems), nil)
+		return nil, nil, nil, false
+	}
+
+	items = make([]T, len(raw))
+	decodeErrs = make([][]models.FieldError, len(raw))
+	invalid = make([]*itemError, len(raw))
+	for i, data := range raw {
+		errs, err := decodeRecord(data, &items[i], dateField)
+		if err != nil {
+			invalid[i] = &itemError{http.StatusBadRequest, models.ErrorResponse{Code: apierror.BadRequest, Message: err.Error()}}
+			continue
+		}
+		decodeErrs[i] = errs
+	}
+	return items, decodeErrs, invalid, true
+}
+
+// validationErrors отмечает в invalid элементы с ошибками разбора полей
+// decodeErrs или валидации fieldErrs. Уже отмеченные элементы не меняются.
+func validationErrors(invalid []*itemError, decodeErrs, fieldErrs [][]models.FieldError) {
+	for i := range invalid {
+		if invalid[i] != nil {
+			continue
+		}
+		if errs := withDecodeErrors(decodeErrs[i], fieldErrs[i]); len(errs) > 0 {
+			invalid[i] = &itemError{http.StatusUnprocessableEntity, models.ErrorResponse{Code: apierror.ValidationFailed, Message: "Validation failed", Details: errs}}
+		}
+	}
+}
+
+// markDuplicates отмечает элементы, ключ которых уже встречался в пачке.
+// Элементы, которые не удалось разобрать, ключа не имеют и пропускаются.
+func markDuplicates[K comparable](invalid []*itemError, key func(i int) K) {
+	first := map[K]int{}
+	for i := range invalid {
+		if e := invalid[i]; e != nil && e.status == http.StatusBadRequest {
+			continue
+		}
+		k := key(i)
+		if j, ok := first[k]; ok {
+			invalid[i] = &itemError{http.StatusConflict, models.ErrorResponse{Code: apierror.Conflict, Message: "Duplicate key in batch", Details: map[string]int{"duplicate_of": j}}}
+			continue
+		}
+		first[k] = i
+	}
+}
+
+// pick возвращает элементы items с номерами idx.
+func pick[T any](items []T, idx []int) []T {
+	picked := make([]T, len(idx))
+	for k, i := range idx {
+		picked[k] = items[i]
+	}
+	return picked
+}
+
+// saveBatch сохраняет элементы пачки без ошибок проверки invalid функцией
+// save, которая получает их номера и возвращает ошибку по каждому.
+// В режиме atomic при ошибках проверки save не вызывается. id, если задана,
+// возвращает ID k-го сохраненного элемента. Ошибка результата означает,
+// что пачка не сохранена из-за сбоя хранилища. Succeeded и Failed результата
+// в сумме дают число элементов.
+func saveBatch(mode string, invalid []*itemError, save func(idx []int) ([]error, error), id func(k int) int) (models.BatchResult, error) {
+	result := models.BatchResult{Mode: mode, Total: len(invalid), Items: make([]models.BatchItemResult, len(invalid))}
+	var valid []int
+	for i, e := range invalid {
+		result.Items[i].Index = i
+		if e == nil {
+			valid = append(valid, i)
+			continue
+		}
+		resp := e.resp
+		result.Items[i].Status, result.Items[i].Error = e.status, &resp
+		result.Failed++
+	}
+
+	atomic := mode == models.BatchAtomic
+	if len(valid) > 0 && !(atomic && result.Failed > 0) {
+		errs, err := save(valid)
+		if err != nil {
+			return result, err
+		}
+		for k, i := range valid {
+			if errs[k] == nil {
+				continue
+			}
+			status, resp, ok := clientStoreError(errs[k])
+			if !ok {
+				return result, errs[k]
+			}
+			result.Items[i].Status, result.Items[i].Error = status, &resp
+			result.Failed++
+		}
+		if !atomic || result.Failed == 0 {
+			for k, i := range valid {
+				if errs[k] == nil {
+					result.Items[i].Status = http.StatusOK
+					if id != nil {
+						result.Items[i].ID = id(k)
+					}
+					result.Succeeded++
+				}
+			}
+			return result, nil
+		}
+	}
+
+	// Режим atomic: пачка отвергнута, элементы без ошибок не сохранены.
+	for _, i := range valid {
+		if result.Items[i].Status == 0 {
+			result.Items[i].Status = http.StatusFailedDependency
+			result.Items[i].Error = &models.ErrorResponse{Code: apierror.NotApplied, Message: "Not saved because other items failed"}
+			result.Failed++
+		}
+	}
+	return result, nil
+}
+
+// writeBatchResult отвечает итогом записи пачки: 200, если сохранены все
+// элементы, 207 при частичном сохранении в режиме best_effort и 422,
+// если пачка в режиме atomic отвергнута.
+func writeBatchResult(w http.ResponseWriter, result models.BatchResult) {
+	status := http.StatusOK
+	switch {
+	case result.Failed > 0 && result.Mode == models.BatchAtomic:
+		status = http.StatusUnprocessableEntity
+	case result.Failed > 0:
+		status = http.StatusMultiStatus
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
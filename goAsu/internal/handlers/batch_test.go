@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"goAsu/internal/apierror"
+	"goAsu/internal/civil"
+	"goAsu/internal/config"
+	"goAsu/internal/models"
+	"goAsu/internal/repository"
+	"goAsu/internal/repository/memory"
+	"goAsu/internal/validation"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDayRecordsBatch(t *testing.T) {
+	const (
+		valid     = `{"well":4455,"date_fact":"2024-12-08","debit":10,"ee_consume":20,"expenses":3,"pump_operating":24}`
+		other     = `{"well":4456,"date_fact":"2024-12-08","debit":11,"ee_consume":21,"expenses":4,"pump_operating":12}`
+		overPump  = `{"well":4456,"date_fact":"2024-12-08","debit":11,"ee_consume":21,"expenses":4,"pump_operating":25}`
+		badDate   = `{"well":4456,"date_fact":"08.12.2024","debit":11,"ee_consume":21,"expenses":4,"pump_operating":12}`
+		unknown   = `{"well":99,"date_fact":"2024-12-08","debit":11,"ee_consume":21,"expenses":4,"pump_operating":12}`
+		malformed = `{"well":"4456"}`
+		plan      = `{"well":4455,"date_plan":"2025-01-15","debit":10,"ee_consume":20,"expenses":3,"pump_operating":24}`
+		badPlan   = `{"well":4456,"date_plan":"2025-01-15","debit":-1,"ee_consume":20,"expenses":3,"pump_operating":24}`
+	)
+	tests := []struct {
+		name     string
+		target   string
+		body     string
+		status   int
+		statuses []int
+		codes    []string
+		saved    bool
+	}{
+		{"atomic", "/well_day_histories/batch", "[" + valid + "," + other + "]", http.StatusOK, []int{200, 200}, []string{"", ""}, true},
+		{"atomic with invalid item", "/well_day_histories/batch", "[" + valid + "," + overPump + "]", http.StatusUnprocessableEntity, []int{424, 422}, []string{apierror.NotApplied, apierror.ValidationFailed}, false},
+		{"best effort with invalid item", "/well_day_histories/batch?mode=best_effort", "[" + valid + "," + overPump + "]", http.StatusMultiStatus, []int{200, 422}, []string{"", apierror.ValidationFailed}, true},
+		{"invalid date", "/well_day_histories/batch?mode=best_effort", "[" + valid + "," + badDate + "]", http.StatusMultiStatus, []int{200, 422}, []string{"", apierror.ValidationFailed}, true},
+		{"unknown well", "/well_day_histories/batch?mode=best_effort", "[" + valid + "," + unknown + "]", http.StatusMultiStatus, []int{200, 422}, []string{"", apierror.ValidationFailed}, true},
+		{"malformed item", "/well_day_histories/batch", "[" + valid + "," + malformed + "]", http.StatusUnprocessableEntity, []int{424, 400}, []string{apierror.NotApplied, apierror.BadRequest}, false},
+		{"duplicate key", "/well_day_histories/batch?mode=best_effort", "[" + valid + "," + valid + "]", http.StatusMultiStatus, []int{200, 409}, []string{"", apierror.Conflict}, true},
+		{"all items invalid", "/well_day_histories/batch?mode=best_effort", "[" + overPump + "]", http.StatusMultiStatus, []int{422}, []string{apierror.ValidationFailed}, false},
+		{"plans", "/well_day_plans/batch", "[" + plan + "]", http.StatusOK, []int{200}, []string{""}, false},
+		{"plans atomic with invalid item", "/well_day_plans/batch", "[" + plan + "," + badPlan + "]", http.StatusUnprocessableEntity, []int{424, 422}, []string{apierror.NotApplied, apierror.ValidationFailed}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.NewDemo()
+			validator := validation.New(config.ValidationConfig{}, store.Wells)
+			mux := http.NewServeMux()
+			mux.Handle("/well_day_histories/batch", WellDayHistoriesBatchHandler(store.Histories, validator))
+			mux.Handle("/well_day_plans/batch", WellDayPlansBatchHandler(store.Plans, validator))
+
+			result := batchResult(t, serve(t, mux, "POST", tt.target, tt.body), tt.status)
+			assertItemStatuses(t, result, tt.statuses...)
+			for i, code := range tt.codes {
+				var got string
+				if result.Items[i].Error != nil {
+					got = result.Items[i].Error.Code
+				}
+				if got != code {
+					t.Errorf("item %d code = %q, want %q", i, got, code)
+				}
+			}
+			if succeeded := countStatus(result, http.StatusOK); result.Succeeded != succeeded || result.Failed != result.Total-succeeded {
+				t.Errorf("succeeded = %d, failed = %d of %d, want %d succeeded", result.Succeeded, result.Failed, result.Total, succeeded)
+			}
+			_, err := store.Histories.Get(ctx, 4455, civil.Date{Year: 2024, Month: time.December, Day: 8})
+			if saved := err == nil; saved != tt.saved {
+				t.Errorf("history of 4455 saved = %v, want %v", saved, tt.saved)
+			}
+		})
+	}
+}
+
+func TestBatchRequestErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		status int
+	}{
+		{"unknown mode", "/well_day_histories/batch?mode=all", `[{}]`, http.StatusBadRequest},
+		{"empty batch", "/well_day_histories/batch", `[]`, http.StatusBadRequest},
+		{"not an array", "/well_day_histories/batch", `{}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.NewDemo()
+			h := WellDayHistoriesBatchHandler(store.Histories, validation.New(config.ValidationConfig{}, store.Wells))
+			if rec := serve(t, h, "POST", tt.target, tt.body); rec.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
+
+func TestObjectsBatch(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewDemo()
+	h := ObjectsBatchHandler(store.Objects, store.ObjectTypes)
+	rec := serve(t, h, "POST", "/objects/batch?mode=best_effort", `[{"name":"Куст 103","type":3},{"id":1,"name":"НГДУ-2","type":1},{"name":"Куст 104","type":99}]`)
+	result := batchResult(t, rec, http.StatusMultiStatus)
+	assertItemStatuses(t, result, http.StatusOK, http.StatusOK, http.StatusUnprocessableEntity)
+	if result.Items[0].ID == 0 {
+		t.Fatal("created object has no id")
+	}
+	if obj, err := store.Objects.Get(ctx, result.Items[0].ID); err != nil || obj.Name != "Куст 103" {
+		t.Errorf("created object = %+v, %v", obj, err)
+	}
+	if obj, err := store.Objects.Get(ctx, 1); err != nil || obj.Name != "НГДУ-2" {
+		t.Errorf("updated object = %+v, %v", obj, err)
+	}
+}
+
+func TestSaveBatch(t *testing.T) {
+	invalidItem := &itemError{http.StatusUnprocessableEntity, models.ErrorResponse{Code: apierror.ValidationFailed}}
+	reference := &repository.ConstraintError{Err: repository.ErrInvalidReference, Constraint: "well_day_histories_well_fkey"}
+	tests := []struct {
+		name      string
+		mode      string
+		invalid   []*itemError
+		saveErrs  []error
+		saveErr   error
+		saved     bool
+		statuses  []int
+		succeeded int
+		wantErr   bool
+	}{
+		{"atomic", models.BatchAtomic, []*itemError{nil, nil}, []error{nil, nil}, nil, true, []int{200, 200}, 2, false},
+		{"atomic skips save after check errors", models.BatchAtomic, []*itemError{nil, invalidItem}, nil, nil, false, []int{424, 422}, 0, false},
+		{"atomic with store rejection", models.BatchAtomic, []*itemError{nil, nil}, []error{nil, reference}, nil, true, []int{424, 422}, 0, false},
+		{"best effort with store rejection", models.BatchBestEffort, []*itemError{nil, nil, invalidItem}, []error{reference, nil}, nil, true, []int{422, 200, 422}, 1, false},
+		{"store failure", models.BatchBestEffort, []*itemError{nil}, nil, errors.New("connection reset"), true, nil, 0, true},
+		{"unexpected item error", models.BatchBestEffort, []*itemError{nil}, []error{errors.New("disk full")}, nil, true, nil, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := false
+			result, err := saveBatch(tt.mode, tt.invalid, func(idx []int) ([]error, error) {
+				saved = true
+				return tt.saveErrs, tt.saveErr
+			}, nil)
+			if saved != tt.saved {
+				t.Errorf("save called = %v, want %v", saved, tt.saved)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("saveBatch() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			assertItemStatuses(t, result, tt.statuses...)
+			if result.Succeeded != tt.succeeded || result.Failed != len(tt.invalid)-tt.succeeded {
+				t.Errorf("succeeded = %d, failed = %d, want %d succeeded of %d", result.Succeeded, result.Failed, tt.succeeded, len(tt.invalid))
+			}
+		})
+	}
+}
+
+func countStatus(result models.BatchResult, status int) int {
+	n := 0
+	for _, item := range result.Items {
+		if item.Status == status {
+			n++
+		}
+	}
+	return n
+}
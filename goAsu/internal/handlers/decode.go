@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"goAsu/internal/apierror"
+	"goAsu/internal/civil"
+	"goAsu/internal/models"
+	"goAsu/internal/repository"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// dateFormatError — ошибка поля с датой не в формате YYYY-MM-DD.
+func dateFormatError(field string) models.FieldError {
+	return models.FieldError{Field: field, Message: "must be a valid date in YYYY-MM-DD format"}
+}
+
+// readRecord читает дневную запись из тела запроса в v (см. decodeRecord).
+func readRecord(r *http.Request, v interface{}, dateField string) ([]models.FieldError, error) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	return decodeRecord(data, v, dateField)
+}
+
+// decodeRecord разбирает дневную запись data в v. Дата dateField в неверном
+// формате не делает запись неразборчивой: остальные поля читаются, дата
+// остается прежней, а ее ошибка возвращается списком ошибок полей, чтобы
+// клиент получил ее в ответе 422 вместе с остальными нарушениями.
+// Прочие ошибки разбора возвращаются как error.
+func decodeRecord(data []byte, v interface{}, dateField string) ([]models.FieldError, error) {
+	err := json.Unmarshal(data, v)
+	var dateErr *civil.ParseError
+	if !errors.As(err, &dateErr) {
+		return nil, err
+	}
+	// Разбор повторяется без даты, чтобы не пропустить ошибки других полей.
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, dateField)
+	rest, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(rest, v); err != nil {
+		return nil, err
+	}
+	return []models.FieldError{dateFormatError(dateField)}, nil
+}
+
+// withDecodeErrors объединяет ошибки разбора записи decodeErrs с ошибками
+// валидации errs. Ошибки валидации полей, которые не удалось прочитать,
+// отбрасываются: например, «is required» для даты в неверном формате.
+func withDecodeErrors(decodeErrs, errs []models.FieldError) []models.FieldError {
+	if len(decodeErrs) == 0 {
+		return errs
+	}
+	failed := map[string]bool{}
+	for _, e := range decodeErrs {
+		failed[e.Field] = true
+	}
+	merged := append([]models.FieldError{}, decodeErrs...)
+	for _, e := range errs {
+		if !failed[e.Field] {
+			merged = append(merged, e)
+		}
+	}
+	return merged
+}
+
+// readPatch читает тело запроса PATCH — документ JSON Merge Patch
+// (RFC 7386), который должен быть объектом.
+func readPatch(r *http.Request) (map[string]interface{}, error) {
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+	var patch interface{}
+	if err := dec.Decode(&patch); err != nil {
+		return nil, err
+	}
+	obj, ok := patch.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("request body must be a JSON object")
+	}
+	return obj, nil
+}
+
+// mergePatch применяет patch к документу target по правилам RFC 7386:
+// null удаляет поле, объекты объединяются рекурсивно, остальные значения
+// заменяются. target изменяется, patch — нет.
+func mergePatch(target, patch interface{}) interface{} {
+	fields, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	doc, ok := target.(map[string]interface{})
+	if !ok {
+		doc = map[string]interface{}{}
+	}
+	for name, value := range fields {
+		if value == nil {
+			delete(doc, name)
+		} else {
+			doc[name] = mergePatch(doc[name], value)
+		}
+	}
+	return doc
+}
+
+// applyPatch применяет patch к записи v. Удаленное патчем поле получает
+// нулевое значение, неизвестное поле — ошибка. Дата dateField в неверном
+// формате, как в decodeRecord, возвращается ошибкой поля, а дата записи
+// остается прежней.
+func applyPatch(v interface{}, patch map[string]interface{}, dateField string) ([]models.FieldError, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	date, hasDate := doc[dateField]
+	mergePatch(doc, patch)
+
+	err = decodeStrict(doc, v)
+	var dateErr *civil.ParseError
+	if dateField == "" || !errors.As(err, &dateErr) {
+		return nil, err
+	}
+	delete(doc, dateField)
+	if hasDate {
+		doc[dateField] = date
+	}
+	if err := decodeStrict(doc, v); err != nil {
+		return nil, err
+	}
+	return []models.FieldError{dateFormatError(dateField)}, nil
+}
+
+// decodeStrict записывает документ doc в v, предварительно обнулив v,
+// и отвергает поля, которых нет в v.
+func decodeStrict(doc map[string]interface{}, v interface{}) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(v).Elem()
+	rv.Set(reflect.Zero(rv.Type()))
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// errStalePatch сообщает, что запись изменилась после чтения и патч нужно
+// применить к ее новой версии.
+var errStalePatch = errors.New("record changed while patching")
+
+// maxPatchAttempts ограничивает число попыток применить патч к записи,
+// которую каждый раз успевают изменить другие запросы.
+const maxPatchAttempts = 3
+
+// retryPatch сообщает, применять ли патч заново после неудачной попытки
+// attempt (считая с 1). Если нет, отвечает клиенту: отмененный запрос
+// не повторяется, а после maxPatchAttempts попыток возвращается 412,
+// если клиент передал If-Match, иначе 409.
+func retryPatch(w http.ResponseWriter, r *http.Request, attempt int) bool {
+	if err := r.Context().Err(); err != nil {
+		storeError(w, r, err)
+		return false
+	}
+	if attempt < maxPatchAttempts {
+		return true
+	}
+	if r.Header.Get("If-Match") != "" {
+		storeError(w, r, repository.ErrPreconditionFailed)
+	} else {
+		apierror.Write(w, r, http.StatusConflict, apierror.ConcurrentUpdate, "Record is being changed by other requests, retry later", nil)
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"goAsu/internal/apierror"
+	"goAsu/internal/metrics"
+	"goAsu/internal/middleware"
+	"goAsu/internal/models"
+	"goAsu/internal/repository"
+	"log"
+	"net/http"
+)
+
+func badRequest(w http.ResponseWriter, r *http.Request, message string) {
+	apierror.Write(w, r, http.StatusBadRequest, apierror.BadRequest, message, nil)
+}
+
+// bodyError отвечает на ошибку чтения тела запроса: 413, если тело
+// превышает допустимый размер, иначе 400.
+func bodyError(w http.ResponseWriter, r *http.Request, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		apierror.Write(w, r, http.StatusRequestEntityTooLarge, apierror.PayloadTooLarge, "Request body is too large", nil)
+		return
+	}
+	badRequest(w, r, err.Error())
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	apierror.Write(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed", nil)
+}
+
+// writeValidationError отвечает 422 со списком недопустимых полей записи.
+func writeValidationError(w http.ResponseWriter, r *http.Request, errs []models.FieldError) {
+	apierror.Write(w, r, http.StatusUnprocessableEntity, apierror.ValidationFailed, "Validation failed", errs)
+}
+
+// storeError отвечает клиенту по ошибке хранилища. Ошибки, вызванные
+// данными клиента, получают свой статус и код; текст прочих ошибок
+// записывается в журнал сервера и клиенту не передается.
+func storeError(w http.ResponseWriter, r *http.Request, err error) {
+	if status, resp, ok := clientStoreError(err); ok {
+		apierror.Write(w, r, status, resp.Code, resp.Message, resp.Details)
+		return
+	}
+
+	switch {
+	case errors.Is(err, repository.ErrCanceled), errors.Is(err, context.Canceled):
+		metrics.QueryInterrupted("canceled")
+		log.Printf("%s %s [%s]: canceled by client", r.Method, r.URL.Path, middleware.RequestIDFromContext(r.Context()))
+		apierror.Write(w, r, apierror.StatusClientClosedRequest, apierror.Canceled, "Request canceled", nil)
+	case errors.Is(err, repository.ErrTimeout):
+		metrics.QueryInterrupted("timeout")
+		log.Printf("%s %s [%s]: %v", r.Method, r.URL.Path, middleware.RequestIDFromContext(r.Context()), err)
+		apierror.Write(w, r, http.StatusGatewayTimeout, apierror.Timeout, "Database query timed out", nil)
+	default:
+		log.Printf("%s %s [%s]: %v", r.Method, r.URL.Path, middleware.RequestIDFromContext(r.Context()), err)
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.Internal, "Internal server error", nil)
+	}
+}
+
+// clientStoreError возвращает статус и описание ошибки хранилища, вызванной
+// данными клиента. Для прочих ошибок ok ложно.
+func clientStoreError(err error) (status int, resp models.ErrorResponse, ok bool) {
+	var details interface{}
+	var constraint *repository.ConstraintError
+	if errors.As(err, &constraint) && (constraint.Constraint != "" || constraint.Column != "") {
+		details = map[string]string{"constraint": constraint.Constraint, "column": constraint.Column}
+	}
+
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound, models.ErrorResponse{Code: apierror.NotFound, Message: "Not found"}, true
+	case errors.Is(err, repository.ErrInUse):
+		return http.StatusConflict, models.ErrorResponse{Code: apierror.InUse, Message: "Record is referenced by other records"}, true
+	case errors.Is(err, repository.ErrConflict):
+		return http.StatusConflict, models.ErrorResponse{Code: apierror.Conflict, Message: "Record already exists", Details: details}, true
+	case errors.Is(err, repository.ErrInvalidReference):
+		return http.StatusUnprocessableEntity, models.ErrorResponse{Code: apierror.InvalidReference, Message: "Record references a missing record", Details: details}, true
+	case errors.Is(err, repository.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed, models.ErrorResponse{Code: apierror.PreconditionFailed, Message: "Record has been changed: If-Match or If-None-Match precondition failed"}, true
+	case errors.Is(err, repository.ErrInvalidValue):
+		return http.StatusUnprocessableEntity, models.ErrorResponse{Code: apierror.InvalidValue, Message: "Invalid field value", Details: details}, true
+	}
+	return 0, models.ErrorResponse{}, false
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"goAsu/internal/repository"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// dayETag возвращает ETag дневной записи. Ключ записи задан адресом,
+// поэтому ETag вычисляется только по значениям показателей.
+func dayETag(debit, eeConsume, expenses, pumpOperating float64) string {
+	h := sha256.New()
+	for _, v := range []float64{debit, eeConsume, expenses, pumpOperating} {
+		h.Write(strconv.AppendFloat(nil, v, 'g', -1, 64))
+		h.Write([]byte{0})
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// matchETag сообщает, соответствует ли etag заголовку If-Match или
+// If-None-Match: списку ETag через запятую или "*". Пустой etag означает,
+// что записи нет, и не соответствует ничему.
+func matchETag(header, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	for _, v := range strings.Split(header, ",") {
+		if v = strings.TrimSpace(v); v == "*" || v == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPreconditions проверяет заголовки If-Match и If-None-Match запроса
+// на изменение по ETag текущей записи (пустой строке, если записи нет)
+// и возвращает repository.ErrPreconditionFailed, если они не выполнены.
+func checkPreconditions(r *http.Request, etag string) error {
+	if header := r.Header.Get("If-Match"); header != "" && !matchETag(header, etag) {
+		return repository.ErrPreconditionFailed
+	}
+	if header := r.Header.Get("If-None-Match"); header != "" && matchETag(header, etag) {
+		return repository.ErrPreconditionFailed
+	}
+	return nil
+}
+
+// notModified отвечает 304, если ETag записи соответствует заголовку
+// If-None-Match запроса GET, и сообщает, что ответ отправлен.
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" && matchETag(header, etag) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
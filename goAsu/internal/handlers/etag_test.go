@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"goAsu/internal/config"
+	"goAsu/internal/repository/memory"
+	"goAsu/internal/validation"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMatchETag(t *testing.T) {
+	tests := []struct {
+		header string
+		etag   string
+		want   bool
+	}{
+		{`"a"`, `"a"`, true},
+		{`"b", "a"`, `"a"`, true},
+		{`"b"`, `"a"`, false},
+		{`*`, `"a"`, true},
+		{`*`, "", false},
+		{`"a"`, "", false},
+	}
+	for _, tt := range tests {
+		if got := matchETag(tt.header, tt.etag); got != tt.want {
+			t.Errorf("matchETag(%q, %q) = %v, want %v", tt.header, tt.etag, got, tt.want)
+		}
+	}
+}
+
+func TestDayRecordPreconditions(t *testing.T) {
+	const (
+		history = "/wells/4455/history/2024-12-01"
+		plan    = "/wells/4455/plans/2024-12-01"
+		body    = `{"debit":20,"ee_consume":54,"expenses":4.5,"pump_operating":24}`
+		stale   = `"0123456789abcdef0123456789abcdef"`
+		current = "current"
+	)
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		header string
+		value  string
+		status int
+	}{
+		{"get not modified", "GET", history, "", "If-None-Match", current, http.StatusNotModified},
+		{"get modified", "GET", history, "", "If-None-Match", stale, http.StatusOK},
+		{"put matching", "PUT", history, body, "If-Match", current, http.StatusOK},
+		{"put stale", "PUT", history, body, "If-Match", stale, http.StatusPreconditionFailed},
+		{"put any existing", "PUT", history, body, "If-Match", "*", http.StatusOK},
+		{"put any missing", "PUT", "/wells/4455/history/2024-12-09", body, "If-Match", "*", http.StatusPreconditionFailed},
+		{"put create only existing", "PUT", history, body, "If-None-Match", "*", http.StatusPreconditionFailed},
+		{"put one of several", "PUT", history, body, "If-Match", stale + ", " + current, http.StatusOK},
+		{"patch matching", "PATCH", history, `{"debit":20}`, "If-Match", current, http.StatusOK},
+		{"patch stale", "PATCH", history, `{"debit":20}`, "If-Match", stale, http.StatusPreconditionFailed},
+		{"plan get not modified", "GET", plan, "", "If-None-Match", current, http.StatusNotModified},
+		{"plan put stale", "PUT", plan, body, "If-Match", stale, http.StatusPreconditionFailed},
+		{"plan patch matching", "PATCH", plan, `{"debit":20}`, "If-Match", current, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.NewDemo()
+			validator := validation.New(config.ValidationConfig{}, store.Wells)
+			mux := http.NewServeMux()
+			mux.Handle("/wells/{well}/history/{date}", WellDayHistoryHandler(store.Histories, validator))
+			mux.Handle("/wells/{well}/plans/{date}", WellDayPlanHandler(store.Plans, validator))
+
+			// current в значении заголовка заменяется ETag записи до запроса.
+			etag := serve(t, mux, "GET", tt.target, "").Header().Get("ETag")
+			value := strings.ReplaceAll(tt.value, current, etag)
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(tt.header, value)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if rec.Code == http.StatusNotModified && rec.Header().Get("ETag") != value {
+				t.Errorf("304 ETag = %q, want %q", rec.Header().Get("ETag"), value)
+			}
+			if rec.Code == http.StatusOK && tt.method != "GET" {
+				after := serve(t, mux, "GET", tt.target, "").Header().Get("ETag")
+				if rec.Header().Get("ETag") != after {
+					t.Errorf("response ETag = %q, want the stored record's %q", rec.Header().Get("ETag"), after)
+				}
+			}
+		})
+	}
+}
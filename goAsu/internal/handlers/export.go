@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"goAsu/internal/export"
+	"goAsu/internal/query"
+	"io"
+	"net/http"
+	"time"
+)
+
+// exportWriteTimeout ограничивает передачу каждой порции выгрузки. Срок
+// server.write_timeout рассчитан на обычные ответы и для выгрузки снимается,
+// чтобы большой файл не обрывался на середине; медленный клиент, который
+// перестал принимать данные, по-прежнему отключается.
+const exportWriteTimeout = time.Minute
+
+// exportFormat определяет формат ответа по параметру format, а без него —
+// по заголовку Accept. Пустая строка означает обычный ответ JSON.
+func exportFormat(r *http.Request) (string, error) {
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		format, _ := export.FormatFromAccept(r.Header.Get("Accept"))
+		return format, nil
+	}
+	if name == "json" {
+		return "", nil
+	}
+	format, ok := export.FormatFromName(name)
+	if !ok {
+		return "", errors.New("Invalid format: use json, csv, xlsx or ndjson")
+	}
+	return format, nil
+}
+
+// exportAll снимает ограничение числа записей по умолчанию:
+// без явного параметра limit выгружается вся выборка.
+func exportAll(list query.List, r *http.Request) query.List {
+	if !r.URL.Query().Has("limit") {
+		list.Limit = 0
+	}
+	return list
+}
+
+// writeExport передает клиенту записи, которые перечисляет each, в формате
+// format по мере их получения. name задает имя файла без расширения.
+func writeExport[T any](w http.ResponseWriter, r *http.Request, format, name string, each func(fn func(T) error) error) {
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+
+	out := &sentWriter{w: w, rc: http.NewResponseController(w)}
+	enc, err := export.NewEncoder[T](out, format)
+	if err == nil {
+		err = each(enc.Encode)
+	}
+	if err == nil {
+		err = enc.Close()
+	}
+	if err == nil {
+		return
+	}
+	if !out.sent {
+		w.Header().Del("Content-Disposition")
+		storeError(w, r, err)
+		return
+	}
+	// Часть файла уже отправлена: обрываем соединение, чтобы клиент
+	// не принял неполную выгрузку за целую.
+	panic(http.ErrAbortHandler)
+}
+
+// eachOf перечисляет записи уже полученного среза, например строки отчета.
+func eachOf[T any](items []T) func(fn func(T) error) error {
+	return func(fn func(T) error) error {
+		for _, item := range items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// sentWriter запоминает, начата ли отправка тела ответа, и перед каждой
+// записью продлевает срок передачи на exportWriteTimeout.
+type sentWriter struct {
+	w    io.Writer
+	rc   *http.ResponseController
+	sent bool
+}
+
+func (s *sentWriter) Write(p []byte) (int, error) {
+	s.sent = true
+	// Ошибка означает, что соединение не поддерживает сроки (например,
+	// в тестах); тогда действует срок сервера.
+	s.rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+	return s.w.Write(p)
+}
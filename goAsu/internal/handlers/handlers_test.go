@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"goAsu/internal/apierror"
+	"goAsu/internal/civil"
+	"goAsu/internal/config"
+	"goAsu/internal/models"
+	"goAsu/internal/repository"
+	"goAsu/internal/repository/memory"
+	"goAsu/internal/validation"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer возвращает маршруты справочников и дневных записей поверх
+// демонстрационного хранилища в памяти.
+func newTestServer(t *testing.T) (http.Handler, *repository.Store) {
+	t.Helper()
+	store := memory.NewDemo()
+	validator := validation.New(config.ValidationConfig{}, store.Wells)
+
+	mux := http.NewServeMux()
+	mux.Handle("/objects/{id}", ObjectHandler(store.Objects, store.ObjectTypes))
+	mux.Handle("/wells", WellsHandler(store.Wells))
+	mux.Handle("/wells/batch", WellsBatchHandler(store.Wells))
+	mux.Handle("/wells/{well}", WellHandler(store.Wells))
+	mux.Handle("/wells/{well}/history/{date}", WellDayHistoryHandler(store.Histories, validator))
+	return mux, store
+}
+
+func serve(t *testing.T, h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// errorCode возвращает код ошибки из тела ответа.
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var resp models.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode error response %q: %v", rec.Body.String(), err)
+	}
+	return resp.Code
+}
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		code   string
+	}{
+		{"create well with missing object", "POST", "/wells", `{"well":1,"ngdu":1,"cdng":2,"kust":4,"mest":99}`, http.StatusUnprocessableEntity, apierror.InvalidReference},
+		{"replace well with missing object", "PUT", "/wells/4455", `{"ngdu":99,"cdng":2,"kust":4,"mest":6}`, http.StatusUnprocessableEntity, apierror.InvalidReference},
+		{"patch well with missing object", "PATCH", "/wells/4455", `{"kust":99}`, http.StatusUnprocessableEntity, apierror.InvalidReference},
+		{"patch object with missing type", "PATCH", "/objects/1", `{"type":99}`, http.StatusUnprocessableEntity, apierror.InvalidReference},
+		{"delete object used by well", "DELETE", "/objects/4", "", http.StatusConflict, apierror.InUse},
+		{"delete well with history", "DELETE", "/wells/4455", "", http.StatusConflict, apierror.InUse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestServer(t)
+			rec := serve(t, h, tt.method, tt.target, tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if code := errorCode(t, rec); code != tt.code {
+				t.Errorf("code = %q, want %q", code, tt.code)
+			}
+		})
+	}
+}
+
+func TestCreateWellWithExistingObjects(t *testing.T) {
+	ctx := context.Background()
+	h, store := newTestServer(t)
+	rec := serve(t, h, "POST", "/wells", `{"well":1,"ngdu":1,"cdng":2,"kust":4,"mest":6}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+	if _, err := store.Wells.Get(ctx, 1); err != nil {
+		t.Errorf("well not saved: %v", err)
+	}
+}
+
+func TestDeleteUnusedObject(t *testing.T) {
+	ctx := context.Background()
+	h, store := newTestServer(t)
+	obj := models.Object{Name: "Куст 103", Type: 3}
+	if err := store.Objects.Create(ctx, &obj); err != nil {
+		t.Fatal(err)
+	}
+	rec := serve(t, h, "DELETE", "/objects/"+strconv.Itoa(obj.ID), "")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusNoContent, rec.Body)
+	}
+}
+
+func TestWellsBatchReferences(t *testing.T) {
+	ctx := context.Background()
+	body := `[{"well":1,"ngdu":1,"cdng":2,"kust":4,"mest":6},{"well":2,"ngdu":1,"cdng":2,"kust":99,"mest":6}]`
+
+	t.Run("atomic", func(t *testing.T) {
+		h, store := newTestServer(t)
+		rec := serve(t, h, "POST", "/wells/batch", body)
+		result := batchResult(t, rec, http.StatusUnprocessableEntity)
+		assertItemStatuses(t, result, http.StatusFailedDependency, http.StatusUnprocessableEntity)
+		if _, err := store.Wells.Get(ctx, 1); err == nil {
+			t.Error("atomic batch saved a well despite an invalid item")
+		}
+	})
+	t.Run("best effort", func(t *testing.T) {
+		h, store := newTestServer(t)
+		rec := serve(t, h, "POST", "/wells/batch?mode=best_effort", body)
+		result := batchResult(t, rec, http.StatusMultiStatus)
+		assertItemStatuses(t, result, http.StatusOK, http.StatusUnprocessableEntity)
+		if result.Items[1].Error == nil || result.Items[1].Error.Code != apierror.InvalidReference {
+			t.Errorf("item 1 error = %+v, want code %q", result.Items[1].Error, apierror.InvalidReference)
+		}
+		if _, err := store.Wells.Get(ctx, 1); err != nil {
+			t.Errorf("valid well not saved: %v", err)
+		}
+	})
+}
+
+func TestPatchWellDayHistory(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   models.WellDayHistory
+	}{
+		{"changes only given fields", `{"debit":20}`, http.StatusOK, models.WellDayHistory{Debit: 20, EEConsume: 54, Expenses: 4.5, PumpOperating: 24}},
+		{"null resets a field", `{"expenses":null}`, http.StatusOK, models.WellDayHistory{Debit: 18, EEConsume: 54, PumpOperating: 24}},
+		{"unknown field", `{"debt":20}`, http.StatusBadRequest, models.WellDayHistory{}},
+		{"not an object", `[{"debit":20}]`, http.StatusBadRequest, models.WellDayHistory{}},
+		{"key change", `{"well":4456}`, http.StatusBadRequest, models.WellDayHistory{}},
+		{"invalid date", `{"date_fact":"01.12.2024"}`, http.StatusUnprocessableEntity, models.WellDayHistory{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestServer(t)
+			rec := serve(t, h, "PATCH", "/wells/4455/history/2024-12-01", tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var got models.WellDayHistory
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			tt.want.Well, tt.want.DateFact = got.Well, got.DateFact
+			if got != tt.want {
+				t.Errorf("history = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPutWellDayHistoryCreateOnly(t *testing.T) {
+	h, _ := newTestServer(t)
+	put := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/wells/4455/history/2024-12-07", strings.NewReader(`{"debit":1,"ee_consume":1,"expenses":1,"pump_operating":1}`))
+		req.Header.Set("If-None-Match", "*")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := put(); rec.Code != http.StatusCreated {
+		t.Fatalf("first PUT status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+	rec := put()
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("second PUT status = %d, want %d: %s", rec.Code, http.StatusPreconditionFailed, rec.Body)
+	}
+	if code := errorCode(t, rec); code != apierror.PreconditionFailed {
+		t.Errorf("code = %q, want %q", code, apierror.PreconditionFailed)
+	}
+}
+
+func batchResult(t *testing.T, rec *httptest.ResponseRecorder, status int) models.BatchResult {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, status, rec.Body)
+	}
+	var result models.BatchResult
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func assertItemStatuses(t *testing.T, result models.BatchResult, statuses ...int) {
+	t.Helper()
+	if len(result.Items) != len(statuses) {
+		t.Fatalf("got %d items, want %d", len(result.Items), len(statuses))
+	}
+	for i, status := range statuses {
+		if result.Items[i].Status != status {
+			t.Errorf("item %d status = %d, want %d", i, result.Items[i].Status, status)
+		}
+	}
+}
+
+// racingHistories изменяет запись перед каждым сохранением, как если бы
+// ее все время правили параллельные запросы.
+type racingHistories struct {
+	repository.WellDayHistoryRepository
+	puts int
+}
+
+func (r *racingHistories) Put(ctx context.Context, history models.WellDayHistory, check func(*models.WellDayHistory) error) (bool, error) {
+	r.puts++
+	current, err := r.WellDayHistoryRepository.Get(ctx, history.Well, history.DateFact)
+	if err != nil {
+		return false, err
+	}
+	current.Expenses++
+	if err := r.WellDayHistoryRepository.Update(ctx, current); err != nil {
+		return false, err
+	}
+	return r.WellDayHistoryRepository.Put(ctx, history, check)
+}
+
+func TestPatchWellDayHistoryRetries(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch bool
+		cancel  bool
+		status  int
+		code    string
+		puts    int
+	}{
+		{"gives up after max attempts", false, false, http.StatusConflict, apierror.ConcurrentUpdate, maxPatchAttempts},
+		{"with If-Match", true, false, http.StatusPreconditionFailed, apierror.PreconditionFailed, 1},
+		{"canceled request", false, true, apierror.StatusClientClosedRequest, apierror.Canceled, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.NewDemo()
+			histories := &racingHistories{WellDayHistoryRepository: store.Histories}
+			h := WellDayHistoryHandler(histories, validation.New(config.ValidationConfig{}, store.Wells))
+
+			req := httptest.NewRequest("PATCH", "/wells/4455/history/2024-12-01", strings.NewReader(`{"debit":20}`))
+			req.SetPathValue("well", "4455")
+			req.SetPathValue("date", "2024-12-01")
+			if tt.ifMatch {
+				current, err := store.Histories.Get(context.Background(), 4455, civil.Date{Year: 2024, Month: time.December, Day: 1})
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set("If-Match", historyETag(current))
+			}
+			if tt.cancel {
+				ctx, cancel := context.WithCancel(req.Context())
+				cancel()
+				req = req.WithContext(ctx)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if code := errorCode(t, rec); code != tt.code {
+				t.Errorf("code = %q, want %q", code, tt.code)
+			}
+			if histories.puts != tt.puts {
+				t.Errorf("record saved %d times, want %d", histories.puts, tt.puts)
+			}
+		})
+	}
+}
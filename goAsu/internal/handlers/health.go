@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"goAsu/internal/models"
+	"net/http"
+	"time"
+)
+
+// readyTimeout ограничивает время проверки готовности.
+const readyTimeout = 2 * time.Second
+
+// ReadyCheck проверяет готовность хранилища.
+type ReadyCheck func(ctx context.Context) models.Readiness
+
+// @Summary Проверка работоспособности
+// @Description Отвечает 200, пока процесс сервера работает. Не обращается к базе данных и не требует аутентификации.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /healthz [get]
+func HealthzHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	}
+}
+
+// @Summary Проверка готовности
+// @Description Проверяет, что база данных отвечает и схема обновлена до последней миграции.
+// @Description Отвечает 200, если сервис готов принимать запросы, и 503, если нет. Не требует аутентификации.
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.Readiness
+// @Failure 503 {object} models.Readiness
+// @Router /readyz [get]
+func ReadyzHandler(check ReadyCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+		ready := check(ctx)
+
+		w.Header().Set("Content-Type", "application/json")
+		if ready.Status != models.Ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(ready)
+	}
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"goAsu/internal/apierror"
+	"goAsu/internal/importer"
+	"goAsu/internal/metrics"
+	"goAsu/internal/models"
+	"goAsu/internal/repository"
+	"goAsu/internal/validation"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxImportSize ограничивает размер загружаемого файла.
+const maxImportSize = 32 << 20
+
+type importFunc func(ctx context.Context, format string, r io.Reader, dryRun bool) (models.ImportResult, error)
+
+func WellDayHistoriesImportHandler(histories repository.WellDayHistoryRepository, validator *validation.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			importWellDayHistories(histories, validator, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+func WellDayPlansImportHandler(plans repository.WellDayPlanRepository, validator *validation.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			importWellDayPlans(plans, validator, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+// importWellDayHistories загружает историю дневных данных из файла CSV или XLSX.
+// @Summary Загрузка истории дневных данных из файла
+// @Description Принимает файл CSV или XLSX со столбцами well, date_fact, debit, ee_consume, expenses, pump_operating.
+// @Description Все строки проверяются до записи, в том числе правилами валидации; при наличии ошибок данные не сохраняются и возвращается 422 со списком ошибок по строкам.
+// @Description Записи с существующим ключом (well, date_fact) заменяются. Загрузка выполняется в одной транзакции.
+// @Description Файл передается полем file формы multipart/form-data или телом запроса с Content-Type text/csv
+// @Description либо application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.
+// @Description Требуется роль operator или admin.
+// @Tags well_day_histories
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Файл CSV или XLSX"
+// @Param format query string false "Формат файла, если его нельзя определить по имени или Content-Type" Enums(csv, xlsx)
+// @Param dry_run query bool false "Только проверить файл, не сохраняя данные"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ"
+// @Success 200 {object} models.ImportResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 422 {object} models.ImportResult
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /well_day_histories/import [post]
+func importWellDayHistories(histories repository.WellDayHistoryRepository, validator *validation.Validator, w http.ResponseWriter, r *http.Request) {
+	runImport(w, r, metrics.Histories, func(ctx context.Context, format string, body io.Reader, dryRun bool) (models.ImportResult, error) {
+		return importer.Histories(ctx, histories, validator, format, body, dryRun)
+	})
+}
+
+// importWellDayPlans загружает плановые дни из файла CSV или XLSX.
+// @Summary Загрузка плановых данных из файла
+// @Description Принимает файл CSV или XLSX со столбцами well, date_plan, debit, ee_consume, expenses, pump_operating.
+// @Description Все строки проверяются до записи, в том числе правилами валидации; при наличии ошибок данные не сохраняются и возвращается 422 со списком ошибок по строкам.
+// @Description Записи с существующим ключом (well, date_plan) заменяются. Загрузка выполняется в одной транзакции.
+// @Description Файл передается полем file формы multipart/form-data или телом запроса с Content-Type text/csv
+// @Description либо application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.
+// @Description Требуется роль planner или admin.
+// @Tags well_day_plans
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Файл CSV или XLSX"
+// @Param format query string false "Формат файла, если его нельзя определить по имени или Content-Type" Enums(csv, xlsx)
+// @Param dry_run query bool false "Только проверить файл, не сохраняя данные"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ"
+// @Success 200 {object} models.ImportResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 422 {object} models.ImportResult
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /well_day_plans/import [post]
+func importWellDayPlans(plans repository.WellDayPlanRepository, validator *validation.Validator, w http.ResponseWriter, r *http.Request) {
+	runImport(w, r, metrics.Plans, func(ctx context.Context, format string, body io.Reader, dryRun bool) (models.ImportResult, error) {
+		return importer.Plans(ctx, plans, validator, format, body, dryRun)
+	})
+}
+
+func runImport(w http.ResponseWriter, r *http.Request, resource string, fn importFunc) {
+	dryRun := false
+	if raw := r.URL.Query().Get("dry_run"); raw != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			badRequest(w, r, "Invalid dry_run")
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	body, format, err := importBody(r)
+	if err != nil {
+		bodyError(w, r, err)
+		return
+	}
+	defer body.Close()
+	if format != importer.CSV && format != importer.XLSX {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.UnsupportedFormat, "Unsupported file format: use CSV or XLSX", nil)
+		return
+	}
+
+	result, err := fn(r.Context(), format, body, dryRun)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+	if !dryRun {
+		metrics.RecordsWritten(resource, metrics.SourceImport, result.Imported)
+	}
+
+	status := http.StatusOK
+	if len(result.Errors) > 0 && !dryRun {
+		status = http.StatusUnprocessableEntity
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
+
+// importBody возвращает содержимое загружаемого файла и его формат.
+// Формат берется из параметра format, затем из имени файла формы
+// или заголовка Content-Type; пустая строка означает, что формат не определен.
+func importBody(r *http.Request) (io.ReadCloser, string, error) {
+	format := r.URL.Query().Get("format")
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, "", err
+		}
+		if format == "" {
+			format, _ = importer.FormatFromName(header.Filename)
+		}
+		if format == "" {
+			format, _ = importer.FormatFromContentType(header.Header.Get("Content-Type"))
+		}
+		return file, format, nil
+	}
+
+	if format == "" {
+		format, _ = importer.FormatFromContentType(r.Header.Get("Content-Type"))
+	}
+	return r.Body, format, nil
+}
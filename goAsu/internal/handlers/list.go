@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"errors"
+	"goAsu/internal/civil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// setTotalCount сообщает клиенту общее число записей, удовлетворяющих фильтрам,
+// без учета limit и offset.
+func setTotalCount(w http.ResponseWriter, total int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+}
+
+// parseAsOf разбирает параметр as_of: момент в формате RFC 3339 или дату
+// YYYY-MM-DD (начало суток UTC). Возвращает nil, если параметр не задан.
+func parseAsOf(r *http.Request) (*time.Time, error) {
+	raw := r.URL.Query().Get("as_of")
+	if raw == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		return &t, nil
+	}
+	if t, err := time.Parse(civil.Layout, raw); err == nil {
+		return &t, nil
+	}
+	return nil, errors.New("Invalid as_of: use RFC 3339 timestamp or YYYY-MM-DD")
+}
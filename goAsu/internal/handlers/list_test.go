@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseAsOf(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"?as_of=2024-12-05", time.Date(2024, time.December, 5, 0, 0, 0, 0, time.UTC), false},
+		{"?as_of=2024-12-05T10:30:00Z", time.Date(2024, time.December, 5, 10, 30, 0, 0, time.UTC), false},
+		{"?as_of=2024-12-05T13:30:00.5%2B03:00", time.Date(2024, time.December, 5, 10, 30, 0, 5e8, time.UTC), false},
+		{"?as_of=05.12.2024", time.Time{}, true},
+		{"?as_of=2024-12-05T10:30", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseAsOf(httptest.NewRequest("GET", "/well_day_histories"+tt.query, nil))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseAsOf(%q) error = %v, want error %v", tt.query, err, tt.wantErr)
+			continue
+		}
+		if tt.want.IsZero() {
+			if got != nil {
+				t.Errorf("parseAsOf(%q) = %v, want nil", tt.query, got)
+			}
+			continue
+		}
+		if got == nil || !got.Equal(tt.want) {
+			t.Errorf("parseAsOf(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"goAsu/internal/apierror"
+	"goAsu/internal/models"
+	"goAsu/internal/repository"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func ObjectTypesHandler(objectTypes repository.ObjectTypeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			getObjectTypes(objectTypes, w, r)
+		case "POST":
+			createObjectType(objectTypes, w, r)
+		case "PUT":
+			updateObjectType(objectTypes, w, r)
+		case "DELETE":
+			deleteObjectType(objectTypes, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+// @Summary Получение всех типов объектов
+// @Description Возвращает справочник типов объектов
+// @Tags object_types
+// @Produce  json
+// @Param id query int false "Фильтр по ID типа объекта"
+// @Success 200 {array} models.ObjectType
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /object_types [get]
+func getObjectTypes(objectTypes repository.ObjectTypeRepository, w http.ResponseWriter, r *http.Request) {
+	list, err := objectTypes.List(r.Context())
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	// Справочник невелик, поэтому фильтр по ID применяется к полному списку.
+	if raw := r.URL.Query().Get("id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			badRequest(w, r, "Invalid ID")
+			return
+		}
+		filtered := []models.ObjectType{}
+		for _, objType := range list {
+			if objType.ID == id {
+				filtered = append(filtered, objType)
+			}
+		}
+		list = filtered
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// @Summary Создание нового типа объекта
+// @Description Создает новый тип объекта
+// @Description Требуется роль admin.
+// @Tags object_types
+// @Accept  json
+// @Produce  json
+// @Param object_type body models.ObjectType true "Создаваемый тип объекта"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ"
+// @Success 201 {object} models.ObjectType
+// @Header 201 {string} Location "Адрес созданной записи"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /object_types [post]
+func createObjectType(objectTypes repository.ObjectTypeRepository, w http.ResponseWriter, r *http.Request) {
+	var objType models.ObjectType
+	if err := json.NewDecoder(r.Body).Decode(&objType); err != nil {
+		bodyError(w, r, err)
+		return
+	}
+	if !checkObjectTypeName(w, r, objType) {
+		return
+	}
+
+	if err := objectTypes.Create(r.Context(), &objType); err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	created(w, "/object_types/"+strconv.Itoa(objType.ID), objType)
+}
+
+// @Summary Обновление типа объекта
+// @Description Обновляет наименование типа объекта
+// @Description Требуется роль admin.
+// @Tags object_types
+// @Accept  json
+// @Produce  json
+// @Param object_type body models.ObjectType true "Обновляемый тип объекта"
+// @Success 200 {object} models.ObjectType
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /object_types [put]
+func updateObjectType(objectTypes repository.ObjectTypeRepository, w http.ResponseWriter, r *http.Request) {
+	var objType models.ObjectType
+	if err := json.NewDecoder(r.Body).Decode(&objType); err != nil {
+		bodyError(w, r, err)
+		return
+	}
+	if !checkObjectTypeName(w, r, objType) {
+		return
+	}
+
+	err := objectTypes.Update(r.Context(), objType)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(objType)
+}
+
+// @Summary Удаление типа объекта
+// @Description Удаляет тип объекта. Тип, на который ссылаются объекты, удалить нельзя.
+// @Description Требуется роль admin.
+// @Tags object_types
+// @Param id query int true "ID типа объекта"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /object_types [delete]
+func deleteObjectType(objectTypes repository.ObjectTypeRepository, w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		badRequest(w, r, "Invalid ID")
+		return
+	}
+
+	err = objectTypes.Delete(r.Context(), id)
+	if errors.Is(err, repository.ErrInUse) {
+		apierror.Write(w, r, http.StatusConflict, apierror.InUse, "Object type is referenced by objects", nil)
+		return
+	}
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func ObjectTypeHandler(objectTypes repository.ObjectTypeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			getObjectType(objectTypes, w, r)
+		case "PUT":
+			replaceObjectType(objectTypes, w, r)
+		case "DELETE":
+			deleteObjectTypeByID(objectTypes, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+// @Summary Получение типа объекта
+// @Description Возвращает тип объекта по ID
+// @Tags object_types
+// @Produce json
+// @Param id path int true "ID типа объекта"
+// @Success 200 {object} models.ObjectType
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /object_types/{id} [get]
+func getObjectType(objectTypes repository.ObjectTypeRepository, w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt(r, "id")
+	if !ok {
+		badRequest(w, r, "Invalid ID")
+		return
+	}
+
+	objType, err := objectTypes.Get(r.Context(), id)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(objType)
+}
+
+// @Summary Замена типа объекта
+// @Description Заменяет наименование типа объекта. ID берется из пути; если он указан в теле, он должен совпадать.
+// @Description Требуется роль admin.
+// @Tags object_types
+// @Accept json
+// @Produce json
+// @Param id path int true "ID типа объекта"
+// @Param object_type body models.ObjectType true "Новые значения полей типа объекта"
+// @Success 200 {object} models.ObjectType
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /object_types/{id} [put]
+func replaceObjectType(objectTypes repository.ObjectTypeRepository, w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt(r, "id")
+	if !ok {
+		badRequest(w, r, "Invalid ID")
+		return
+	}
+	var objType models.ObjectType
+	if err := json.NewDecoder(r.Body).Decode(&objType); err != nil {
+		bodyError(w, r, err)
+		return
+	}
+	if !checkObjectTypeName(w, r, objType) {
+		return
+	}
+	if objType.ID != 0 && objType.ID != id {
+		badRequest(w, r, "ID in body does not match path")
+		return
+	}
+	objType.ID = id
+
+	if err := objectTypes.Update(r.Context(), objType); err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(objType)
+}
+
+// @Summary Удаление типа объекта по ID
+// @Description Удаляет тип объекта. Тип, на который ссылаются объекты, удалить нельзя.
+// @Description Требуется роль admin.
+// @Tags object_types
+// @Param id path int true "ID типа объекта"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /object_types/{id} [delete]
+func deleteObjectTypeByID(objectTypes repository.ObjectTypeRepository, w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt(r, "id")
+	if !ok {
+		badRequest(w, r, "Invalid ID")
+		return
+	}
+
+	err := objectTypes.Delete(r.Context(), id)
+	if errors.Is(err, repository.ErrInUse) {
+		apierror.Write(w, r, http.StatusConflict, apierror.InUse, "Object type is referenced by objects", nil)
+		return
+	}
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkObjectTypeName отвечает 422, если наименование типа объекта пустое,
+// и сообщает, можно ли сохранять тип.
+func checkObjectTypeName(w http.ResponseWriter, r *http.Request, objType models.ObjectType) bool {
+	if strings.TrimSpace(objType.Name) == "" {
+		writeValidationError(w, r, []models.FieldError{{Field: "name", Message: "is required"}})
+		return false
+	}
+	return true
+}
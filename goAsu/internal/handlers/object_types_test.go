@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"goAsu/internal/apierror"
+	"goAsu/internal/repository/memory"
+	"net/http"
+	"testing"
+)
+
+func TestObjectTypeName(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{"create", "POST", "/object_types", `{"name":"Скважина"}`, http.StatusCreated},
+		{"create without name", "POST", "/object_types", `{}`, http.StatusUnprocessableEntity},
+		{"create with blank name", "POST", "/object_types", `{"name":"  "}`, http.StatusUnprocessableEntity},
+		{"update with empty name", "PUT", "/object_types", `{"id":1,"name":""}`, http.StatusUnprocessableEntity},
+		{"replace", "PUT", "/object_types/1", `{"name":"НГДУ"}`, http.StatusOK},
+		{"replace with blank name", "PUT", "/object_types/1", "{\"name\":\"\\t\"}", http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.NewDemo()
+			mux := http.NewServeMux()
+			mux.Handle("/object_types", ObjectTypesHandler(store.ObjectTypes))
+			mux.Handle("/object_types/{id}", ObjectTypeHandler(store.ObjectTypes))
+
+			rec := serve(t, mux, tt.method, tt.target, tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if rec.Code == http.StatusUnprocessableEntity {
+				if code := errorCode(t, rec); code != apierror.ValidationFailed {
+					t.Errorf("code = %q, want %q", code, apierror.ValidationFailed)
+				}
+			}
+		})
+	}
+}
@@ -1,160 +1,396 @@
-package handlers
-
-import (
-	"database/sql"
-	"encoding/json"
-	"goAsu/internal/models"
-	"net/http"
-	"strconv"
-)
-
-func ObjectsHandler(db *sql.DB) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case "GET":
-			getObjects(db, w, r)
-		case "POST":
-			createObject(db, w, r)
-		case "PUT":
-			updateObject(db, w, r)
-		case "DELETE":
-			deleteObject(db, w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	}
-}
-
-// @Summary Получение всех объектов
-// @Description Возвращает все объекты
-// @Tags objects
-// @Produce  json
-// @Success 200 {array} models.Object
-// @Failure 500 {string} string "Internal Server Error"
-// @Router /objects [get]
-func getObjects(db *sql.DB, w http.ResponseWriter, r *http.Request) {
-	rows, err := db.Query("SELECT id, name, type FROM objects")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
-
-	var objects []models.Object
-	for rows.Next() {
-		var obj models.Object
-		if err := rows.Scan(&obj.ID, &obj.Name, &obj.Type); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		objects = append(objects, obj)
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(objects)
-}
-
-// @Summary Создание нового объекта
-// @Description Создает новый объект
-// @Tags objects
-// @Accept  json
-// @Produce  json
-// @Param object body models.Object true "Создаваемый объект"
-// @Success 201 {string} string "Created"
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
-// @Router /objects [post]
-func createObject(db *sql.DB, w http.ResponseWriter, r *http.Request) {
-	var obj models.Object
-	if err := json.NewDecoder(r.Body).Decode(&obj); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	sqlStatement := `INSERT INTO objects (name, type) VALUES ($1, $2) RETURNING id`
-	id := 0
-	err := db.QueryRow(sqlStatement, obj.Name, obj.Type).Scan(&id)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	obj.ID = id
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(obj)
-}
-
-// @Summary Обновление объекта
-// @Description Обновляет объект
-// @Tags objects
-// @Accept  json
-// @Produce  json
-// @Param object body models.Object true "Обновляемый объект"
-// @Success 200 {string} string "OK"
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
-// @Router /objects [put]
-func updateObject(db *sql.DB, w http.ResponseWriter, r *http.Request) {
-	var obj models.Object
-	if err := json.NewDecoder(r.Body).Decode(&obj); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	sqlStatement := `UPDATE objects SET name=$1, type=$2 WHERE id=$3`
-	res, err := db.Exec(sqlStatement, obj.Name, obj.Type, obj.ID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	count, err := res.RowsAffected()
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	if count == 0 {
-		http.Error(w, "No rows affected", http.StatusNotFound)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(obj)
-}
-
-// @Summary Удаление объекта
-// @Description Удаляет объект
-// @Tags objects
-// @Param id query int true "ID объекта"
-// @Success 200 {string} string "OK"
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
-// @Router /objects [delete]
-func deleteObject(db *sql.DB, w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(r.URL.Query().Get("id"))
-	if err != nil {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
-		return
-	}
-
-	sqlStatement := `DELETE FROM objects WHERE id=$1`
-	res, err := db.Exec(sqlStatement, id)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	count, err := res.RowsAffected()
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	if count == 0 {
-		http.Error(w, "No rows affected", http.StatusNotFound)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
-}
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"goAsu/internal/apierror"
+	"goAsu/internal/models"
+	"goAsu/internal/query"
+	"goAsu/internal/repository"
+	"net/http"
+	"strconv"
+)
+
+var objectsQuery = query.Spec{
+	Filters: []query.FilterSpec{
+		{Param: "id", Field: "id", Op: query.Eq, Kind: query.Int},
+		{Param: "type", Field: "type", Op: query.Eq, Kind: query.Int},
+		{Param: "name", Field: "name", Op: query.Contains, Kind: query.String},
+	},
+	Sortable: []string{"id", "name", "type"},
+	Key:      []string{"id"},
+}
+
+func ObjectsHandler(objects repository.ObjectRepository, objectTypes repository.ObjectTypeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			getObjects(objects, w, r)
+		case "POST":
+			createObject(objects, objectTypes, w, r)
+		case "PUT":
+			updateObject(objects, objectTypes, w, r)
+		case "DELETE":
+			deleteObject(objects, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+// @Summary Получение всех объектов
+// @Description Возвращает объекты с фильтрацией, сортировкой и постраничным выводом.
+// @Description С параметром embed=type в ответ добавляется наименование типа объекта.
+// @Tags objects
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/x-ndjson
+// @Param id query []int false "Фильтр по ID объекта" collectionFormat(csv)
+// @Param type query []int false "Фильтр по типу объекта" collectionFormat(csv)
+// @Param name query string false "Поиск по подстроке наименования"
+// @Param sort query string false "Сортировка: id, name, type; префикс - означает убывание"
+// @Param limit query int false "Максимальное число записей (по умолчанию 1000, не более 10000; при выгрузке в CSV, XLSX и NDJSON по умолчанию без ограничения)"
+// @Param offset query int false "Число пропускаемых записей"
+// @Param embed query string false "Встраиваемые данные" Enums(type)
+// @Param format query string false "Формат ответа: json, csv, xlsx или ndjson; без параметра определяется заголовком Accept" Enums(json, csv, xlsx, ndjson)
+// @Success 200 {array} models.Object
+// @Header 200 {integer} X-Total-Count "Общее число записей, удовлетворяющих фильтрам"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /objects [get]
+func getObjects(objects repository.ObjectRepository, w http.ResponseWriter, r *http.Request) {
+	list, err := query.Parse(objectsQuery, r.URL.Query())
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	format, err := exportFormat(r)
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	if format != "" {
+		embed := r.URL.Query().Get("embed") == "type"
+		writeExport(w, r, format, "objects", func(fn func(models.Object) error) error {
+			return objects.Each(r.Context(), exportAll(list, r), func(obj models.Object) error {
+				if !embed {
+					obj.TypeName = ""
+				}
+				return fn(obj)
+			})
+		})
+		return
+	}
+
+	page, total, err := objects.List(r.Context(), list)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+	if r.URL.Query().Get("embed") != "type" {
+		for i := range page {
+			page[i].TypeName = ""
+		}
+	}
+
+	setTotalCount(w, total)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// @Summary Создание нового объекта
+// @Description Создает новый объект. Тип объекта должен существовать в справочнике object_types.
+// @Description Требуется роль admin.
+// @Tags objects
+// @Accept  json
+// @Produce  json
+// @Param object body models.Object true "Создаваемый объект"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ"
+// @Success 201 {object} models.Object
+// @Header 201 {string} Location "Адрес созданной записи"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /objects [post]
+func createObject(objects repository.ObjectRepository, objectTypes repository.ObjectTypeRepository, w http.ResponseWriter, r *http.Request) {
+	var obj models.Object
+	if err := json.NewDecoder(r.Body).Decode(&obj); err != nil {
+		bodyError(w, r, err)
+		return
+	}
+	if !checkObjectType(objectTypes, w, r, obj.Type) {
+		return
+	}
+
+	if err := objects.Create(r.Context(), &obj); err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	created(w, "/objects/"+strconv.Itoa(obj.ID), obj)
+}
+
+// @Summary Обновление объекта
+// @Description Обновляет объект. Тип объекта должен существовать в справочнике object_types.
+// @Description Требуется роль admin.
+// @Tags objects
+// @Accept  json
+// @Produce  json
+// @Param object body models.Object true "Обновляемый объект"
+// @Success 200 {object} models.Object
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /objects [put]
+func updateObject(objects repository.ObjectRepository, objectTypes repository.ObjectTypeRepository, w http.ResponseWriter, r *http.Request) {
+	var obj models.Object
+	if err := json.NewDecoder(r.Body).Decode(&obj); err != nil {
+		bodyError(w, r, err)
+		return
+	}
+	if !checkObjectType(objectTypes, w, r, obj.Type) {
+		return
+	}
+
+	err := objects.Update(r.Context(), obj)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(obj)
+}
+
+// @Summary Удаление объекта
+// @Description Удаляет объект
+// @Description Требуется роль admin.
+// @Tags objects
+// @Param id query int true "ID объекта"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /objects [delete]
+func deleteObject(objects repository.ObjectRepository, w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		badRequest(w, r, "Invalid ID")
+		return
+	}
+
+	err = objects.Delete(r.Context(), id)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkObjectType проверяет, что тип объекта есть в справочнике, и при его
+// отсутствии отвечает клиенту ошибкой.
+func checkObjectType(objectTypes repository.ObjectTypeRepository, w http.ResponseWriter, r *http.Request, id int) bool {
+	_, err := objectTypes.Get(r.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		apierror.Write(w, r, http.StatusUnprocessableEntity, apierror.InvalidReference, "Unknown object type", nil)
+		return false
+	}
+	if err != nil {
+		storeError(w, r, err)
+		return false
+	}
+	return true
+}
+
+func ObjectHandler(objects repository.ObjectRepository, objectTypes repository.ObjectTypeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			getObject(objects, w, r)
+		case "PUT":
+			replaceObject(objects, objectTypes, w, r)
+		case "PATCH":
+			patchObject(objects, w, r)
+		case "DELETE":
+			deleteObjectByID(objects, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+// @Summary Получение объекта
+// @Description Возвращает объект по ID вместе с наименованием его типа
+// @Tags objects
+// @Produce json
+// @Param id path int true "ID объекта"
+// @Success 200 {object} models.Object
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /objects/{id} [get]
+func getObject(objects repository.ObjectRepository, w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt(r, "id")
+	if !ok {
+		badRequest(w, r, "Invalid ID")
+		return
+	}
+
+	obj, err := objects.Get(r.Context(), id)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(obj)
+}
+
+// @Summary Замена объекта
+// @Description Заменяет наименование и тип объекта. ID берется из пути; если он указан в теле, он должен совпадать.
+// @Description Требуется роль admin.
+// @Tags objects
+// @Accept json
+// @Produce json
+// @Param id path int true "ID объекта"
+// @Param object body models.Object true "Новые значения полей объекта"
+// @Success 200 {object} models.Object
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /objects/{id} [put]
+func replaceObject(objects repository.ObjectRepository, objectTypes repository.ObjectTypeRepository, w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt(r, "id")
+	if !ok {
+		badRequest(w, r, "Invalid ID")
+		return
+	}
+	var obj models.Object
+	if err := json.NewDecoder(r.Body).Decode(&obj); err != nil {
+		bodyError(w, r, err)
+		return
+	}
+	if obj.ID != 0 && obj.ID != id {
+		badRequest(w, r, "ID in body does not match path")
+		return
+	}
+	obj.ID = id
+	if !checkObjectType(objectTypes, w, r, obj.Type) {
+		return
+	}
+
+	if err := objects.Update(r.Context(), obj); err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(obj)
+}
+
+// @Summary Частичное изменение объекта
+// @Description Изменяет только переданные поля объекта (JSON Merge Patch: null сбрасывает поле, неизвестные поля отвергаются). ID объекта изменить нельзя.
+// @Description Требуется роль admin.
+// @Tags objects
+// @Accept json,application/merge-patch+json
+// @Produce json
+// @Param id path int true "ID объекта"
+// @Param object body models.Object true "Изменяемые поля объекта"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ"
+// @Success 200 {object} models.Object
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /objects/{id} [patch]
+func patchObject(objects repository.ObjectRepository, w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt(r, "id")
+	if !ok {
+		badRequest(w, r, "Invalid ID")
+		return
+	}
+	patch, err := readPatch(r)
+	if err != nil {
+		bodyError(w, r, err)
+		return
+	}
+	// Тип объекта проверяет хранилище: ссылка на несуществующий тип
+	// возвращается как ErrInvalidReference.
+	var patchErr error
+	obj, err := objects.Patch(r.Context(), id, func(obj *models.Object) error {
+		if _, patchErr = applyPatch(obj, patch, ""); patchErr == nil && obj.ID != id {
+			patchErr = errors.New("ID cannot be changed")
+		}
+		return patchErr
+	})
+	if patchErr != nil {
+		bodyError(w, r, patchErr)
+		return
+	}
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(obj)
+}
+
+// @Summary Удаление объекта по ID
+// @Description Удаляет объект. Объект, на который ссылаются скважины, удалить нельзя.
+// @Description Требуется роль admin.
+// @Tags objects
+// @Param id path int true "ID объекта"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /objects/{id} [delete]
+func deleteObjectByID(objects repository.ObjectRepository, w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt(r, "id")
+	if !ok {
+		badRequest(w, r, "Invalid ID")
+		return
+	}
+
+	if err := objects.Delete(r.Context(), id); err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"goAsu/internal/civil"
+	"net/http"
+	"strconv"
+)
+
+// pathInt разбирает целочисленный параметр пути name, например {well}.
+func pathInt(r *http.Request, name string) (int, bool) {
+	value, err := strconv.Atoi(r.PathValue(name))
+	return value, err == nil
+}
+
+// pathDate разбирает параметр пути {date} в формате YYYY-MM-DD.
+func pathDate(r *http.Request) (civil.Date, bool) {
+	date, err := civil.Parse(r.PathValue("date"))
+	return date, err == nil
+}
+
+// dayKeyFromPath разбирает ключ дневной записи из параметров пути {well}
+// и {date}. При ошибке отвечает клиенту 400 и возвращает false.
+func dayKeyFromPath(w http.ResponseWriter, r *http.Request) (int, civil.Date, bool) {
+	well, ok := pathInt(r, "well")
+	if !ok {
+		badRequest(w, r, "Invalid Well ID")
+		return 0, civil.Date{}, false
+	}
+	date, ok := pathDate(r)
+	if !ok {
+		badRequest(w, r, "Invalid Date")
+		return 0, civil.Date{}, false
+	}
+	return well, date, true
+}
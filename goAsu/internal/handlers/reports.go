@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"goAsu/internal/civil"
+	"goAsu/internal/repository"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxPlanFactDays ограничивает период отчета "план-факт": отчет строится
+// в памяти целиком, по строке на каждую скважину и день периода.
+const maxPlanFactDays = 366
+
+func PlanFactReportHandler(reports repository.ReportRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			getPlanFactReport(reports, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+// getPlanFactReport возвращает отклонения фактических показателей от плановых по дням.
+// @Summary Отчет "план-факт" по скважинам
+// @Description Возвращает по каждой скважине и дню плановые и фактические значения debit, ee_consume, expenses и pump_operating,
+// @Description а также абсолютное и процентное отклонение факта от плана.
+// @Description Дни, для которых есть только план или только факт, помечаются статусом plan_only или fact_only.
+// @Description Период отчета — не более 366 дней.
+// @Tags reports
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/x-ndjson
+// @Param well query []int false "ID скважин (можно указать несколько раз или через запятую)" collectionFormat(multi)
+// @Param date_from query string true "Начало периода (YYYY-MM-DD)" Format(date)
+// @Param date_to query string true "Конец периода (YYYY-MM-DD)" Format(date)
+// @Param as_of query string false "Момент времени (RFC 3339 или YYYY-MM-DD), на который возвращаются фактические данные в том виде, в каком они были известны системе"
+// @Param format query string false "Формат ответа: json, csv, xlsx или ndjson; без параметра определяется заголовком Accept" Enums(json, csv, xlsx, ndjson)
+// @Success 200 {array} models.PlanFactDeviation
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /reports/plan_fact [get]
+func getPlanFactReport(reports repository.ReportRepository, w http.ResponseWriter, r *http.Request) {
+	format, err := exportFormat(r)
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	query := r.URL.Query()
+	wells, err := parseIntList(query["well"])
+	if err != nil {
+		badRequest(w, r, "Invalid Well ID")
+		return
+	}
+	dateFrom, dateTo, err := parseDateRange(query.Get("date_from"), query.Get("date_to"))
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	if spanDays(dateFrom, dateTo) > maxPlanFactDays {
+		badRequest(w, r, fmt.Sprintf("Date range must not exceed %d days", maxPlanFactDays))
+		return
+	}
+
+	asOf, err := parseAsOf(r)
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	report, err := reports.PlanFact(r.Context(), repository.PlanFactFilter{
+		Wells:    wells,
+		DateFrom: dateFrom,
+		DateTo:   dateTo,
+		AsOf:     asOf,
+	})
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	if format != "" {
+		writeExport(w, r, format, "plan_fact", eachOf(report))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func ProductionRollupHandler(reports repository.ReportRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			getProductionRollup(reports, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+// maxRollupPeriods ограничивает число периодов свода: свод строится в памяти
+// целиком, по строке на каждый объект и период.
+const maxRollupPeriods = 366
+
+var hierarchyLevels = []string{"ngdu", "cdng", "kust", "mest"}
+
+var rollupPeriods = map[string]bool{
+	"day":   true,
+	"week":  true,
+	"month": true,
+}
+
+var rollupSources = map[string]bool{
+	"fact": true,
+	"plan": true,
+}
+
+// getProductionRollup возвращает показатели, агрегированные по уровню иерархии и периоду.
+// @Summary Свод показателей по иерархии объектов
+// @Description Суммирует debit, ee_consume и expenses и усредняет pump_operating по НГДУ, ЦДНГ, кусту или месторождению
+// @Description с группировкой по дням, неделям или месяцам. Источником служат фактические (fact) или плановые (plan) данные.
+// @Description Период свода — не более 366 дней, недель или месяцев соответственно.
+// @Tags reports
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/x-ndjson
+// @Param level query string true "Уровень иерархии" Enums(ngdu, cdng, kust, mest)
+// @Param period query string false "Период группировки" Enums(day, week, month) default(day)
+// @Param source query string false "Источник данных" Enums(fact, plan) default(fact)
+// @Param date_from query string true "Начало периода (YYYY-MM-DD)" Format(date)
+// @Param date_to query string true "Конец периода (YYYY-MM-DD)" Format(date)
+// @Param ngdu query []int false "Фильтр по НГДУ" collectionFormat(multi)
+// @Param cdng query []int false "Фильтр по ЦДНГ" collectionFormat(multi)
+// @Param kust query []int false "Фильтр по кусту" collectionFormat(multi)
+// @Param mest query []int false "Фильтр по месторождению" collectionFormat(multi)
+// @Param as_of query string false "Момент времени (RFC 3339 или YYYY-MM-DD), на который возвращаются фактические данные в том виде, в каком они были известны системе"
+// @Param format query string false "Формат ответа: json, csv, xlsx или ndjson; без параметра определяется заголовком Accept" Enums(json, csv, xlsx, ndjson)
+// @Success 200 {array} models.ProductionRollup
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /reports/rollup [get]
+func getProductionRollup(reports repository.ReportRepository, w http.ResponseWriter, r *http.Request) {
+	format, err := exportFormat(r)
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	query := r.URL.Query()
+	filter := repository.RollupFilter{
+		Level:     query.Get("level"),
+		Period:    query.Get("period"),
+		Source:    query.Get("source"),
+		Hierarchy: map[string][]int64{},
+	}
+	if !contains(hierarchyLevels, filter.Level) {
+		badRequest(w, r, "Invalid level")
+		return
+	}
+	if filter.Period == "" {
+		filter.Period = "day"
+	}
+	if !rollupPeriods[filter.Period] {
+		badRequest(w, r, "Invalid period")
+		return
+	}
+	if filter.Source == "" {
+		filter.Source = "fact"
+	}
+	if !rollupSources[filter.Source] {
+		badRequest(w, r, "Invalid source")
+		return
+	}
+	filter.DateFrom, filter.DateTo, err = parseDateRange(query.Get("date_from"), query.Get("date_to"))
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	if periodCount(filter.DateFrom, filter.DateTo, filter.Period) > maxRollupPeriods {
+		badRequest(w, r, fmt.Sprintf("Date range must not exceed %d periods of %s", maxRollupPeriods, filter.Period))
+		return
+	}
+	for _, level := range hierarchyLevels {
+		ids, err := parseIntList(query[level])
+		if err != nil {
+			badRequest(w, r, "Invalid "+level)
+			return
+		}
+		filter.Hierarchy[level] = ids
+	}
+
+	if filter.AsOf, err = parseAsOf(r); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	if filter.AsOf != nil && filter.Source != "fact" {
+		badRequest(w, r, "as_of is supported only for source=fact")
+		return
+	}
+
+	rollup, err := reports.Rollup(r.Context(), filter)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	if format != "" {
+		writeExport(w, r, format, "rollup", eachOf(rollup))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rollup)
+}
+
+// parseIntList разбирает значения параметра, переданного несколько раз и/или через запятую.
+func parseIntList(values []string) ([]int64, error) {
+	var result []int64
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			n, err := strconv.ParseInt(part, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, n)
+		}
+	}
+	return result, nil
+}
+
+// parseDateRange проверяет границы периода в формате YYYY-MM-DD.
+func parseDateRange(from, to string) (civil.Date, civil.Date, error) {
+	start, err := civil.Parse(from)
+	if err != nil {
+		return civil.Date{}, civil.Date{}, fmt.Errorf("Invalid date_from")
+	}
+	end, err := civil.Parse(to)
+	if err != nil {
+		return civil.Date{}, civil.Date{}, fmt.Errorf("Invalid date_to")
+	}
+	if end.Before(start) {
+		return civil.Date{}, civil.Date{}, fmt.Errorf("date_to is before date_from")
+	}
+	return start, end, nil
+}
+
+// spanDays возвращает число дней периода [from, to] включительно.
+func spanDays(from, to civil.Date) int {
+	return int(to.Time().Sub(from.Time()).Hours()/24) + 1
+}
+
+// periodCount возвращает число периодов свода (day, week или month),
+// которых касается период [from, to].
+func periodCount(from, to civil.Date, period string) int {
+	switch period {
+	case "week":
+		// Недели начинаются с понедельника.
+		offset := (int(from.Time().Weekday()) + 6) % 7
+		return (offset + spanDays(from, to) + 6) / 7
+	case "month":
+		return (to.Year-from.Year)*12 + int(to.Month-from.Month) + 1
+	}
+	return spanDays(from, to)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"goAsu/internal/civil"
+	"goAsu/internal/repository/memory"
+	"net/http"
+	"testing"
+)
+
+func TestPlanFactReportRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		status int
+	}{
+		{"one week", "date_from=2024-12-01&date_to=2024-12-07", http.StatusOK},
+		{"leap year", "date_from=2024-01-01&date_to=2024-12-31", http.StatusOK},
+		{"too long", "date_from=2024-01-01&date_to=2025-01-01", http.StatusBadRequest},
+		{"no date_from", "date_to=2024-12-07", http.StatusBadRequest},
+		{"no date_to", "date_from=2024-12-01", http.StatusBadRequest},
+		{"reversed", "date_from=2024-12-07&date_to=2024-12-01", http.StatusBadRequest},
+	}
+	h := PlanFactReportHandler(memory.NewDemo().Reports)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, h, "GET", "/reports/plan_fact?"+tt.query, "")
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
+
+func TestRollupRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		status int
+	}{
+		{"days", "period=day&date_from=2024-01-01&date_to=2024-12-31", http.StatusOK},
+		{"too many days", "period=day&date_from=2024-01-01&date_to=2025-01-01", http.StatusBadRequest},
+		{"weeks", "period=week&date_from=2020-01-01&date_to=2026-12-31", http.StatusOK},
+		{"too many weeks", "period=week&date_from=2015-01-01&date_to=2024-12-31", http.StatusBadRequest},
+		{"months", "period=month&date_from=2000-01-01&date_to=2024-12-31", http.StatusOK},
+		{"too many months", "period=month&date_from=1990-01-01&date_to=2024-12-31", http.StatusBadRequest},
+		{"no dates", "period=month", http.StatusBadRequest},
+	}
+	h := ProductionRollupHandler(memory.NewDemo().Reports)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, h, "GET", "/reports/rollup?level=ngdu&"+tt.query, "")
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
+
+func TestPeriodCount(t *testing.T) {
+	tests := []struct {
+		from, to string
+		period   string
+		want     int
+	}{
+		{"2024-12-01", "2024-12-01", "day", 1},
+		{"2024-12-01", "2024-12-31", "day", 31},
+		{"2024-12-02", "2024-12-08", "week", 1}, // понедельник — воскресенье
+		{"2024-12-01", "2024-12-02", "week", 2}, // воскресенье и понедельник
+		{"2024-12-31", "2025-01-01", "month", 2},
+		{"2024-01-15", "2024-12-15", "month", 12},
+	}
+	for _, tt := range tests {
+		from, _ := civil.Parse(tt.from)
+		to, _ := civil.Parse(tt.to)
+		if got := periodCount(from, to, tt.period); got != tt.want {
+			t.Errorf("periodCount(%s, %s, %s) = %d, want %d", tt.from, tt.to, tt.period, got, tt.want)
+		}
+	}
+}
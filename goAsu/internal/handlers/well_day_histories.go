@@ -1,147 +1,533 @@
-package handlers
-
-import (
-	"database/sql"
-	"encoding/json"
-	"goAsu/internal/models"
-	"net/http"
-	"strconv"
-)
-
-func WellDayHistoriesHandler(db *sql.DB) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case "GET":
-			getWellDayHistories(db, w, r)
-		case "POST":
-			createWellDayHistory(db, w, r)
-		case "PUT":
-			updateWellDayHistory(db, w, r)
-		case "DELETE":
-			deleteWellDayHistory(db, w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	}
-}
-
-// getWellDayHistories возвращает историю дневных данных по заданной скважине.
-// @Summary Получение истории дневных данных по скважине
-// @Description Возвращает историю дневных данных по заданной скважине
-// @Tags well_day_histories
-// @Produce json
-// @Param well query int true "ID скважины"
-// @Success 200 {array} models.WellDayHistory
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
-// @Router /well_day_histories [get]
-func getWellDayHistories(db *sql.DB, w http.ResponseWriter, r *http.Request) {
-	rows, err := db.Query("SELECT well, date_fact, debit, ee_consume, expenses, pump_operating FROM well_day_histories")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
-
-	var histories []models.WellDayHistory
-	for rows.Next() {
-		var history models.WellDayHistory
-		if err := rows.Scan(&history.Well, &history.DateFact, &history.Debit, &history.EEConsume, &history.Expenses, &history.PumpOperating); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		histories = append(histories, history)
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(histories)
-}
-
-// createWellDayHistory создает новую запись в истории дневных данных для заданной скважины.
-// @Summary Создание записи в истории дневных данных
-// @Description Создает новую запись в истории дневных данных для заданной скважины
-// @Tags well_day_histories
-// @Accept json
-// @Produce json
-// @Param well body models.WellDayHistory true "Создаваемая запись истории дневных данных"
-// @Success 201 {string} string "Created"
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
-// @Router /well_day_histories [post]
-func createWellDayHistory(db *sql.DB, w http.ResponseWriter, r *http.Request) {
-	var history models.WellDayHistory
-	if err := json.NewDecoder(r.Body).Decode(&history); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	sqlStatement := `INSERT INTO well_day_histories (well, date_fact, debit, ee_consume, expenses, pump_operating) VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err := db.Exec(sqlStatement, history.Well, history.DateFact, history.Debit, history.EEConsume, history.Expenses, history.PumpOperating)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(history)
-}
-
-// updateWellDayHistory обновляет существующую запись в истории дневных данных для заданной скважины.
-// @Summary Обновление записи в истории дневных данных
-// @Description Обновляет существующую запись в истории дневных данных для заданной скважины
-// @Tags well_day_histories
-// @Accept json
-// @Produce json
-// @Param well body models.WellDayHistory true "Обновляемая запись истории дневных данных"
-// @Success 200 {string} string "OK"
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
-// @Router /well_day_histories [put]
-func updateWellDayHistory(db *sql.DB, w http.ResponseWriter, r *http.Request) {
-	var history models.WellDayHistory
-	if err := json.NewDecoder(r.Body).Decode(&history); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	sqlStatement := `UPDATE well_day_histories SET debit=$1, ee_consume=$2, expenses=$3, pump_operating=$4 WHERE well=$5 AND date_fact=$6`
-	_, err := db.Exec(sqlStatement, history.Debit, history.EEConsume, history.Expenses, history.PumpOperating, history.Well, history.DateFact)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(history)
-}
-
-// deleteWellDayHistory удаляет запись из истории дневных данных для заданной скважины.
-// @Summary Удаление записи из истории дневных данных
-// @Description Удаляет запись из истории дневных данных для заданной скважины
-// @Tags well_day_histories
-// @Param id query int true "ID записи истории дневных данных"
-// @Success 200 {string} string "OK"
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
-// @Router /well_day_histories [delete]
-func deleteWellDayHistory(db *sql.DB, w http.ResponseWriter, r *http.Request) {
-	well, err := strconv.Atoi(r.URL.Query().Get("well"))
-	if err != nil {
-		http.Error(w, "Invalid Well ID", http.StatusBadRequest)
-		return
-	}
-	dateFact := r.URL.Query().Get("date_fact")
-	if dateFact == "" {
-		http.Error(w, "Invalid Date", http.StatusBadRequest)
-		return
-	}
-
-	sqlStatement := `DELETE FROM well_day_histories WHERE well=$1 AND date_fact=$2`
-	_, err = db.Exec(sqlStatement, well, dateFact)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
-}
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goAsu/internal/civil"
+	"goAsu/internal/metrics"
+	"goAsu/internal/models"
+	"goAsu/internal/query"
+	"goAsu/internal/repository"
+	"goAsu/internal/validation"
+	"net/http"
+	"strconv"
+)
+
+var wellDayHistoriesQuery = query.Spec{
+	Filters: []query.FilterSpec{
+		{Param: "well", Field: "well", Op: query.Eq, Kind: query.Int},
+		{Param: "date_from", Field: "date_fact", Op: query.Gte, Kind: query.Date},
+		{Param: "date_to", Field: "date_fact", Op: query.Lte, Kind: query.Date},
+	},
+	Sortable: []string{"well", "date_fact", "debit", "ee_consume", "expenses", "pump_operating"},
+	Key:      []string{"well", "date_fact"},
+}
+
+func WellDayHistoriesHandler(histories repository.WellDayHistoryRepository, validator *validation.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			getWellDayHistories(histories, w, r)
+		case "POST":
+			createWellDayHistory(histories, validator, w, r)
+		case "PUT":
+			updateWellDayHistory(histories, validator, w, r)
+		case "DELETE":
+			deleteWellDayHistory(histories, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+// getWellDayHistories возвращает историю дневных данных по заданной скважине.
+// @Summary Получение истории дневных данных по скважине
+// @Description Возвращает историю дневных данных с фильтрацией по скважинам и периоду, сортировкой и постраничным выводом.
+// @Description С параметром as_of возвращаются данные в том виде, в каком они были известны системе в указанный момент.
+// @Tags well_day_histories
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/x-ndjson
+// @Param well query []int false "Фильтр по ID скважины" collectionFormat(csv)
+// @Param date_from query string false "Начало периода (YYYY-MM-DD)" Format(date)
+// @Param date_to query string false "Конец периода (YYYY-MM-DD)" Format(date)
+// @Param sort query string false "Сортировка: well, date_fact, debit, ee_consume, expenses, pump_operating; префикс - означает убывание"
+// @Param limit query int false "Максимальное число записей (по умолчанию 1000, не более 10000; при выгрузке в CSV, XLSX и NDJSON по умолчанию без ограничения)"
+// @Param offset query int false "Число пропускаемых записей"
+// @Param as_of query string false "Момент времени (RFC 3339 или YYYY-MM-DD), на который возвращаются данные в том виде, в каком они были известны системе"
+// @Param format query string false "Формат ответа: json, csv, xlsx или ndjson; без параметра определяется заголовком Accept" Enums(json, csv, xlsx, ndjson)
+// @Success 200 {array} models.WellDayHistory
+// @Header 200 {integer} X-Total-Count "Общее число записей, удовлетворяющих фильтрам"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /well_day_histories [get]
+func getWellDayHistories(histories repository.WellDayHistoryRepository, w http.ResponseWriter, r *http.Request) {
+	list, err := query.Parse(wellDayHistoriesQuery, r.URL.Query())
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	asOf, err := parseAsOf(r)
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	format, err := exportFormat(r)
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	if format != "" {
+		writeExport(w, r, format, "well_day_histories", func(fn func(models.WellDayHistory) error) error {
+			if asOf != nil {
+				return histories.EachAsOf(r.Context(), exportAll(list, r), *asOf, fn)
+			}
+			return histories.Each(r.Context(), exportAll(list, r), fn)
+		})
+		return
+	}
+
+	var page []models.WellDayHistory
+	var total int
+	if asOf != nil {
+		page, total, err = histories.ListAsOf(r.Context(), list, *asOf)
+	} else {
+		page, total, err = histories.List(r.Context(), list)
+	}
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	setTotalCount(w, total)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// createWellDayHistory создает новую запись в истории дневных данных для заданной скважины.
+// @Summary Создание записи в истории дневных данных
+// @Description Создает новую запись в истории дневных данных для заданной скважины
+// @Description Запись проверяется правилами валидации; при нарушениях возвращается 422 со списком ошибок по полям.
+// @Description Требуется роль operator или admin.
+// @Tags well_day_histories
+// @Accept json
+// @Produce json
+// @Param well body models.WellDayHistory true "Создаваемая запись истории дневных данных"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ"
+// @Success 201 {object} models.WellDayHistory
+// @Header 201 {string} Location "Адрес созданной записи"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /well_day_histories [post]
+func createWellDayHistory(histories repository.WellDayHistoryRepository, validator *validation.Validator, w http.ResponseWriter, r *http.Request) {
+	var history models.WellDayHistory
+	decodeErrs, err := readRecord(r, &history, "date_fact")
+	if err != nil {
+		bodyError(w, r, err)
+		return
+	}
+
+	errs, err := validator.History(r.Context(), history)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+	if errs = withDecodeErrors(decodeErrs, errs); len(errs) > 0 {
+		writeValidationError(w, r, errs)
+		return
+	}
+
+	if err := histories.Create(r.Context(), history); err != nil {
+		storeError(w, r, err)
+		return
+	}
+	metrics.RecordsWritten(metrics.Histories, metrics.SourceAPI, 1)
+
+	created(w, fmt.Sprintf("/wells/%d/history/%s", history.Well, history.DateFact), history)
+}
+
+// updateWellDayHistory обновляет существующую запись в истории дневных данных для заданной скважины.
+// @Summary Обновление записи в истории дневных данных
+// @Description Обновляет существующую запись в истории дневных данных для заданной скважины
+// @Description Запись проверяется правилами валидации; при нарушениях возвращается 422 со списком ошибок по полям.
+// @Description Требуется роль operator или admin.
+// @Tags well_day_histories
+// @Accept json
+// @Produce json
+// @Param well body models.WellDayHistory true "Обновляемая запись истории дневных данных"
+// @Success 200 {object} models.WellDayHistory
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /well_day_histories [put]
+func updateWellDayHistory(histories repository.WellDayHistoryRepository, validator *validation.Validator, w http.ResponseWriter, r *http.Request) {
+	var history models.WellDayHistory
+	decodeErrs, err := readRecord(r, &history, "date_fact")
+	if err != nil {
+		bodyError(w, r, err)
+		return
+	}
+
+	errs, err := validator.History(r.Context(), history)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+	if errs = withDecodeErrors(decodeErrs, errs); len(errs) > 0 {
+		writeValidationError(w, r, errs)
+		return
+	}
+
+	if err := histories.Update(r.Context(), history); err != nil {
+		storeError(w, r, err)
+		return
+	}
+	metrics.RecordsWritten(metrics.Histories, metrics.SourceAPI, 1)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// deleteWellDayHistory удаляет запись из истории дневных данных для заданной скважины.
+// @Summary Удаление записи из истории дневных данных
+// @Description Удаляет запись из истории дневных данных для заданной скважины
+// @Description Требуется роль operator или admin.
+// @Tags well_day_histories
+// @Param well query int true "ID скважины"
+// @Param date_fact query string true "Дата (YYYY-MM-DD)" Format(date)
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /well_day_histories [delete]
+func deleteWellDayHistory(histories repository.WellDayHistoryRepository, w http.ResponseWriter, r *http.Request) {
+	well, err := strconv.Atoi(r.URL.Query().Get("well"))
+	if err != nil {
+		badRequest(w, r, "Invalid Well ID")
+		return
+	}
+	dateFact, err := civil.Parse(r.URL.Query().Get("date_fact"))
+	if err != nil {
+		badRequest(w, r, "Invalid Date")
+		return
+	}
+
+	if err := histories.Delete(r.Context(), well, dateFact); err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func WellDayHistoryHandler(histories repository.WellDayHistoryRepository, validator *validation.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			getWellDayHistory(histories, w, r)
+		case "PUT":
+			replaceWellDayHistory(histories, validator, w, r)
+		case "PATCH":
+			patchWellDayHistory(histories, validator, w, r)
+		case "DELETE":
+			deleteWellDayHistoryByKey(histories, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+// @Summary Получение записи истории за день
+// @Description Возвращает записи истории скважины за указанную дату
+// @Tags well_day_histories
+// @Produce json
+// @Param well path int true "ID скважины"
+// @Param date path string true "Дата (YYYY-MM-DD)" Format(date)
+// @Param If-None-Match header string false "ETag записи: если запись не изменилась, возвращается 304"
+// @Success 200 {object} models.WellDayHistory
+// @Header 200 {string} ETag "Версия записи для заголовка If-Match"
+// @Success 304 {string} string "Not Modified"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /wells/{well}/history/{date} [get]
+func getWellDayHistory(histories repository.WellDayHistoryRepository, w http.ResponseWriter, r *http.Request) {
+	well, date, ok := dayKeyFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := histories.Get(r.Context(), well, date)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+	etag := historyETag(history)
+	if notModified(w, r, etag) {
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// @Summary Создание или замена записи истории за день
+// @Description Создает запись истории скважины за указанную дату или заменяет показатели существующей записи. Скважина и дата берутся из пути; если они указаны в теле, они должны совпадать.
+// @Description С заголовком If-Match запись сохраняется, только если ее текущий ETag совпадает с переданным; If-None-Match: * разрешает только создание. Иначе возвращается 412.
+// @Description Запись проверяется правилами валидации; при нарушениях возвращается 422 со списком ошибок по полям.
+// @Description Требуется роль operator или admin.
+// @Tags well_day_histories
+// @Accept json
+// @Produce json
+// @Param well path int true "ID скважины"
+// @Param date path string true "Дата (YYYY-MM-DD)" Format(date)
+// @Param body body models.WellDayHistory true "Новые значения показателей"
+// @Param If-Match header string false "ETag, который должна иметь текущая запись, или * для существующей записи"
+// @Param If-None-Match header string false "* — только создать запись, если ее нет"
+// @Success 200 {object} models.WellDayHistory
+// @Header 200 {string} ETag "Версия сохраненной записи"
+// @Success 201 {object} models.WellDayHistory
+// @Header 201 {string} Location "Адрес созданной записи"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /wells/{well}/history/{date} [put]
+func replaceWellDayHistory(histories repository.WellDayHistoryRepository, validator *validation.Validator, w http.ResponseWriter, r *http.Request) {
+	well, date, ok := dayKeyFromPath(w, r)
+	if !ok {
+		return
+	}
+	var history models.WellDayHistory
+	decodeErrs, err := readRecord(r, &history, "date_fact")
+	if err != nil {
+		bodyError(w, r, err)
+		return
+	}
+	if (history.Well != 0 && history.Well != well) || (!history.DateFact.IsZero() && history.DateFact != date) {
+		badRequest(w, r, "Well ID or date in body does not match path")
+		return
+	}
+	history.Well, history.DateFact = well, date
+
+	saveWellDayHistory(histories, validator, w, r, history, decodeErrs, nil)
+}
+
+// @Summary Частичное изменение записи истории за день
+// @Description Изменяет только переданные показатели (JSON Merge Patch: null сбрасывает поле, неизвестные поля отвергаются). Скважину и дату изменить нельзя. С заголовком If-Match запись изменяется, только если ее текущий ETag совпадает с переданным, иначе возвращается 412. Если запись все время изменяют другие запросы, после трех попыток возвращается 409.
+// @Description Запись проверяется правилами валидации; при нарушениях возвращается 422 со списком ошибок по полям.
+// @Description Требуется роль operator или admin.
+// @Tags well_day_histories
+// @Accept json,application/merge-patch+json
+// @Produce json
+// @Param well path int true "ID скважины"
+// @Param date path string true "Дата (YYYY-MM-DD)" Format(date)
+// @Param body body models.WellDayHistory true "Изменяемые показатели"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ"
+// @Param If-Match header string false "ETag, который должна иметь текущая запись"
+// @Success 200 {object} models.WellDayHistory
+// @Header 200 {string} ETag "Версия сохраненной записи"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /wells/{well}/history/{date} [patch]
+func patchWellDayHistory(histories repository.WellDayHistoryRepository, validator *validation.Validator, w http.ResponseWriter, r *http.Request) {
+	well, date, ok := dayKeyFromPath(w, r)
+	if !ok {
+		return
+	}
+	patch, err := readPatch(r)
+	if err != nil {
+		bodyError(w, r, err)
+		return
+	}
+	// Если запись изменили между чтением и сохранением, патч применяется
+	// к новой версии: так параллельные изменения разных полей не теряются.
+	for attempt := 1; ; attempt++ {
+		history, err := histories.Get(r.Context(), well, date)
+		if err != nil {
+			storeError(w, r, err)
+			return
+		}
+		base := history
+		decodeErrs, err := applyPatch(&history, patch, "date_fact")
+		if err != nil {
+			bodyError(w, r, err)
+			return
+		}
+		if history.Well != well || history.DateFact != date {
+			badRequest(w, r, "Well ID and date cannot be changed")
+			return
+		}
+		if saveWellDayHistory(histories, validator, w, r, history, decodeErrs, &base) || !retryPatch(w, r, attempt) {
+			return
+		}
+	}
+}
+
+// saveWellDayHistory проверяет и сохраняет запись; decodeErrs — ошибки полей,
+// найденные при разборе тела запроса. Если base nil, отсутствующая
+// запись создается. Иначе запись изменяется патчем, примененным к base:
+// для отсутствующей записи возвращается 404, а если сохраненная запись
+// отличается от base, ничего не сохраняется и не отправляется, и функция
+// возвращает false, чтобы патч применили заново. Заголовки If-Match
+// и If-None-Match проверяются по записи, сохраненной в момент изменения.
+func saveWellDayHistory(histories repository.WellDayHistoryRepository, validator *validation.Validator, w http.ResponseWriter, r *http.Request, history models.WellDayHistory, decodeErrs []models.FieldError, base *models.WellDayHistory) bool {
+	errs, err := validator.History(r.Context(), history)
+	if err != nil {
+		storeError(w, r, err)
+		return true
+	}
+	if errs = withDecodeErrors(decodeErrs, errs); len(errs) > 0 {
+		writeValidationError(w, r, errs)
+		return true
+	}
+
+	isNew, err := histories.Put(r.Context(), history, func(current *models.WellDayHistory) error {
+		etag := ""
+		if current != nil {
+			etag = historyETag(*current)
+		} else if base != nil {
+			return repository.ErrNotFound
+		}
+		if err := checkPreconditions(r, etag); err != nil {
+			return err
+		}
+		if base != nil && *current != *base {
+			return errStalePatch
+		}
+		return nil
+	})
+	if errors.Is(err, errStalePatch) {
+		return false
+	}
+	if err != nil {
+		storeError(w, r, err)
+		return true
+	}
+	metrics.RecordsWritten(metrics.Histories, metrics.SourceAPI, 1)
+
+	w.Header().Set("ETag", historyETag(history))
+	if isNew {
+		created(w, fmt.Sprintf("/wells/%d/history/%s", history.Well, history.DateFact), history)
+		return true
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+	return true
+}
+
+// historyETag возвращает ETag записи для заголовков ETag и If-Match.
+func historyETag(history models.WellDayHistory) string {
+	return dayETag(history.Debit, history.EEConsume, history.Expenses, history.PumpOperating)
+}
+
+// @Summary Удаление записи истории за день
+// @Description Удаляет записи истории скважины за указанную дату
+// @Description Требуется роль operator или admin.
+// @Tags well_day_histories
+// @Param well path int true "ID скважины"
+// @Param date path string true "Дата (YYYY-MM-DD)" Format(date)
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /wells/{well}/history/{date} [delete]
+func deleteWellDayHistoryByKey(histories repository.WellDayHistoryRepository, w http.ResponseWriter, r *http.Request) {
+	well, date, ok := dayKeyFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := histories.Delete(r.Context(), well, date); err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func WellDayHistoryVersionsHandler(histories repository.WellDayHistoryRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			getWellDayHistoryVersions(histories, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+// getWellDayHistoryVersions возвращает все версии записи истории за день.
+// @Summary Версии записи истории дневных данных
+// @Description Возвращает все версии записи (well, date_fact), включая удаленные, в порядке их появления.
+// @Description recorded_from и recorded_to задают промежуток, в котором версия была текущей; у текущей версии recorded_to отсутствует.
+// @Tags well_day_histories
+// @Produce json
+// @Param well query int true "ID скважины"
+// @Param date_fact query string true "Дата (YYYY-MM-DD)" Format(date)
+// @Success 200 {array} models.WellDayHistoryVersion
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /well_day_histories/versions [get]
+func getWellDayHistoryVersions(histories repository.WellDayHistoryRepository, w http.ResponseWriter, r *http.Request) {
+	well, err := strconv.Atoi(r.URL.Query().Get("well"))
+	if err != nil {
+		badRequest(w, r, "Invalid Well ID")
+		return
+	}
+	dateFact, err := civil.Parse(r.URL.Query().Get("date_fact"))
+	if err != nil {
+		badRequest(w, r, "Invalid Date")
+		return
+	}
+
+	versions, err := histories.Versions(r.Context(), well, dateFact)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+}
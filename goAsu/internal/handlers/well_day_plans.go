@@ -1,147 +1,469 @@
-package handlers
-
-import (
-	"database/sql"
-	"encoding/json"
-	"goAsu/internal/models"
-	"net/http"
-	"strconv"
-)
-
-func WellDayPlansHandler(db *sql.DB) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case "GET":
-			getWellDayPlans(db, w, r)
-		case "POST":
-			createWellDayPlan(db, w, r)
-		case "PUT":
-			updateWellDayPlan(db, w, r)
-		case "DELETE":
-			deleteWellDayPlan(db, w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	}
-}
-
-// getWellDayPlans возвращает плановые данные по заданной скважине.
-// @Summary Получение плановых данных по скважине
-// @Description Возвращает плановые данные по заданной скважине
-// @Tags well_day_plans
-// @Produce json
-// @Param well query int true "ID скважины"
-// @Success 200 {array} models.WellDayPlan
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
-// @Router /well_day_plans [get]
-func getWellDayPlans(db *sql.DB, w http.ResponseWriter, r *http.Request) {
-	rows, err := db.Query("SELECT well, date_plan, debit, ee_consume, expenses, pump_operating FROM well_day_plans")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
-
-	var plans []models.WellDayPlan
-	for rows.Next() {
-		var plan models.WellDayPlan
-		if err := rows.Scan(&plan.Well, &plan.DatePlan, &plan.Debit, &plan.EEConsume, &plan.Expenses, &plan.PumpOperating); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		plans = append(plans, plan)
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(plans)
-}
-
-// createWellDayPlan создает новый плановый день для заданной скважины.
-// @Summary Создание планового дня
-// @Description Создает новый плановый день для заданной скважины
-// @Tags well_day_plans
-// @Accept json
-// @Produce json
-// @Param well body models.WellDayPlan true "Создаваемый плановый день"
-// @Success 201 {string} string "Created"
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
-// @Router /well_day_plans [post]
-func createWellDayPlan(db *sql.DB, w http.ResponseWriter, r *http.Request) {
-	var plan models.WellDayPlan
-	if err := json.NewDecoder(r.Body).Decode(&plan); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	sqlStatement := `INSERT INTO well_day_plans (well, date_plan, debit, ee_consume, expenses, pump_operating) VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err := db.Exec(sqlStatement, plan.Well, plan.DatePlan, plan.Debit, plan.EEConsume, plan.Expenses, plan.PumpOperating)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(plan)
-}
-
-// updateWellDayPlan обновляет плановый день для заданной скважины.
-// @Summary Обновление планового дня
-// @Description Обновляет плановый день для заданной скважины
-// @Tags well_day_plans
-// @Accept json
-// @Produce json
-// @Param well body models.WellDayPlan true "Обновляемый плановый день"
-// @Success 200 {string} string "OK"
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
-// @Router /well_day_plans [put]
-func updateWellDayPlan(db *sql.DB, w http.ResponseWriter, r *http.Request) {
-	var plan models.WellDayPlan
-	if err := json.NewDecoder(r.Body).Decode(&plan); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	sqlStatement := `UPDATE well_day_plans SET debit=$1, ee_consume=$2, expenses=$3, pump_operating=$4 WHERE well=$5 AND date_plan=$6`
-	_, err := db.Exec(sqlStatement, plan.Debit, plan.EEConsume, plan.Expenses, plan.PumpOperating, plan.Well, plan.DatePlan)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(plan)
-}
-
-// deleteWellDayPlan удаляет плановый день для заданной скважины.
-// @Summary Удаление планового дня
-// @Description Удаляет плановый день для заданной скважины
-// @Tags well_day_plans
-// @Param id query int true "ID планового дня"
-// @Success 200 {string} string "OK"
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
-// @Router /well_day_plans [delete]
-func deleteWellDayPlan(db *sql.DB, w http.ResponseWriter, r *http.Request) {
-	well, err := strconv.Atoi(r.URL.Query().Get("well"))
-	if err != nil {
-		http.Error(w, "Invalid Well ID", http.StatusBadRequest)
-		return
-	}
-	datePlan := r.URL.Query().Get("date_plan")
-	if datePlan == "" {
-		http.Error(w, "Invalid Date", http.StatusBadRequest)
-		return
-	}
-
-	sqlStatement := `DELETE FROM well_day_plans WHERE well=$1 AND date_plan=$2`
-	_, err = db.Exec(sqlStatement, well, datePlan)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
-}
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goAsu/internal/civil"
+	"goAsu/internal/metrics"
+	"goAsu/internal/models"
+	"goAsu/internal/query"
+	"goAsu/internal/repository"
+	"goAsu/internal/validation"
+	"net/http"
+	"strconv"
+)
+
+var wellDayPlansQuery = query.Spec{
+	Filters: []query.FilterSpec{
+		{Param: "well", Field: "well", Op: query.Eq, Kind: query.Int},
+		{Param: "date_from", Field: "date_plan", Op: query.Gte, Kind: query.Date},
+		{Param: "date_to", Field: "date_plan", Op: query.Lte, Kind: query.Date},
+	},
+	Sortable: []string{"well", "date_plan", "debit", "ee_consume", "expenses", "pump_operating"},
+	Key:      []string{"well", "date_plan"},
+}
+
+func WellDayPlansHandler(plans repository.WellDayPlanRepository, validator *validation.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			getWellDayPlans(plans, w, r)
+		case "POST":
+			createWellDayPlan(plans, validator, w, r)
+		case "PUT":
+			updateWellDayPlan(plans, validator, w, r)
+		case "DELETE":
+			deleteWellDayPlan(plans, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+// getWellDayPlans возвращает плановые данные по заданной скважине.
+// @Summary Получение плановых данных по скважине
+// @Description Возвращает плановые данные с фильтрацией по скважинам и периоду, сортировкой и постраничным выводом
+// @Tags well_day_plans
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/x-ndjson
+// @Param well query []int false "Фильтр по ID скважины" collectionFormat(csv)
+// @Param date_from query string false "Начало периода (YYYY-MM-DD)" Format(date)
+// @Param date_to query string false "Конец периода (YYYY-MM-DD)" Format(date)
+// @Param sort query string false "Сортировка: well, date_plan, debit, ee_consume, expenses, pump_operating; префикс - означает убывание"
+// @Param limit query int false "Максимальное число записей (по умолчанию 1000, не более 10000; при выгрузке в CSV, XLSX и NDJSON по умолчанию без ограничения)"
+// @Param offset query int false "Число пропускаемых записей"
+// @Param format query string false "Формат ответа: json, csv, xlsx или ndjson; без параметра определяется заголовком Accept" Enums(json, csv, xlsx, ndjson)
+// @Success 200 {array} models.WellDayPlan
+// @Header 200 {integer} X-Total-Count "Общее число записей, удовлетворяющих фильтрам"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /well_day_plans [get]
+func getWellDayPlans(plans repository.WellDayPlanRepository, w http.ResponseWriter, r *http.Request) {
+	list, err := query.Parse(wellDayPlansQuery, r.URL.Query())
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	format, err := exportFormat(r)
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	if format != "" {
+		writeExport(w, r, format, "well_day_plans", func(fn func(models.WellDayPlan) error) error {
+			return plans.Each(r.Context(), exportAll(list, r), fn)
+		})
+		return
+	}
+
+	page, total, err := plans.List(r.Context(), list)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	setTotalCount(w, total)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// createWellDayPlan создает новый плановый день для заданной скважины.
+// @Summary Создание планового дня
+// @Description Создает новый плановый день для заданной скважины
+// @Description Запись проверяется правилами валидации; при нарушениях возвращается 422 со списком ошибок по полям.
+// @Description Требуется роль planner или admin.
+// @Tags well_day_plans
+// @Accept json
+// @Produce json
+// @Param well body models.WellDayPlan true "Создаваемый плановый день"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ"
+// @Success 201 {object} models.WellDayPlan
+// @Header 201 {string} Location "Адрес созданной записи"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /well_day_plans [post]
+func createWellDayPlan(plans repository.WellDayPlanRepository, validator *validation.Validator, w http.ResponseWriter, r *http.Request) {
+	var plan models.WellDayPlan
+	decodeErrs, err := readRecord(r, &plan, "date_plan")
+	if err != nil {
+		bodyError(w, r, err)
+		return
+	}
+
+	errs, err := validator.Plan(r.Context(), plan)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+	if errs = withDecodeErrors(decodeErrs, errs); len(errs) > 0 {
+		writeValidationError(w, r, errs)
+		return
+	}
+
+	if err := plans.Create(r.Context(), plan); err != nil {
+		storeError(w, r, err)
+		return
+	}
+	metrics.RecordsWritten(metrics.Plans, metrics.SourceAPI, 1)
+
+	created(w, fmt.Sprintf("/wells/%d/plans/%s", plan.Well, plan.DatePlan), plan)
+}
+
+// updateWellDayPlan обновляет плановый день для заданной скважины.
+// @Summary Обновление планового дня
+// @Description Обновляет плановый день для заданной скважины
+// @Description Запись проверяется правилами валидации; при нарушениях возвращается 422 со списком ошибок по полям.
+// @Description Требуется роль planner или admin.
+// @Tags well_day_plans
+// @Accept json
+// @Produce json
+// @Param well body models.WellDayPlan true "Обновляемый плановый день"
+// @Success 200 {object} models.WellDayPlan
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /well_day_plans [put]
+func updateWellDayPlan(plans repository.WellDayPlanRepository, validator *validation.Validator, w http.ResponseWriter, r *http.Request) {
+	var plan models.WellDayPlan
+	decodeErrs, err := readRecord(r, &plan, "date_plan")
+	if err != nil {
+		bodyError(w, r, err)
+		return
+	}
+
+	errs, err := validator.Plan(r.Context(), plan)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+	if errs = withDecodeErrors(decodeErrs, errs); len(errs) > 0 {
+		writeValidationError(w, r, errs)
+		return
+	}
+
+	if err := plans.Update(r.Context(), plan); err != nil {
+		storeError(w, r, err)
+		return
+	}
+	metrics.RecordsWritten(metrics.Plans, metrics.SourceAPI, 1)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
+
+// deleteWellDayPlan удаляет плановый день для заданной скважины.
+// @Summary Удаление планового дня
+// @Description Удаляет плановый день для заданной скважины
+// @Description Требуется роль planner или admin.
+// @Tags well_day_plans
+// @Param well query int true "ID скважины"
+// @Param date_plan query string true "Дата (YYYY-MM-DD)" Format(date)
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /well_day_plans [delete]
+func deleteWellDayPlan(plans repository.WellDayPlanRepository, w http.ResponseWriter, r *http.Request) {
+	well, err := strconv.Atoi(r.URL.Query().Get("well"))
+	if err != nil {
+		badRequest(w, r, "Invalid Well ID")
+		return
+	}
+	datePlan, err := civil.Parse(r.URL.Query().Get("date_plan"))
+	if err != nil {
+		badRequest(w, r, "Invalid Date")
+		return
+	}
+
+	if err := plans.Delete(r.Context(), well, datePlan); err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func WellDayPlanHandler(plans repository.WellDayPlanRepository, validator *validation.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			getWellDayPlan(plans, w, r)
+		case "PUT":
+			replaceWellDayPlan(plans, validator, w, r)
+		case "PATCH":
+			patchWellDayPlan(plans, validator, w, r)
+		case "DELETE":
+			deleteWellDayPlanByKey(plans, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+// @Summary Получение планового дня за день
+// @Description Возвращает планового дня скважины за указанную дату
+// @Tags well_day_plans
+// @Produce json
+// @Param well path int true "ID скважины"
+// @Param date path string true "Дата (YYYY-MM-DD)" Format(date)
+// @Param If-None-Match header string false "ETag записи: если запись не изменилась, возвращается 304"
+// @Success 200 {object} models.WellDayPlan
+// @Header 200 {string} ETag "Версия записи для заголовка If-Match"
+// @Success 304 {string} string "Not Modified"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /wells/{well}/plans/{date} [get]
+func getWellDayPlan(plans repository.WellDayPlanRepository, w http.ResponseWriter, r *http.Request) {
+	well, date, ok := dayKeyFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	plan, err := plans.Get(r.Context(), well, date)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+	etag := planETag(plan)
+	if notModified(w, r, etag) {
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
+
+// @Summary Создание или замена планового дня за день
+// @Description Создает плановый день скважины за указанную дату или заменяет показатели существующего. Скважина и дата берутся из пути; если они указаны в теле, они должны совпадать.
+// @Description С заголовком If-Match запись сохраняется, только если ее текущий ETag совпадает с переданным; If-None-Match: * разрешает только создание. Иначе возвращается 412.
+// @Description Запись проверяется правилами валидации; при нарушениях возвращается 422 со списком ошибок по полям.
+// @Description Требуется роль planner или admin.
+// @Tags well_day_plans
+// @Accept json
+// @Produce json
+// @Param well path int true "ID скважины"
+// @Param date path string true "Дата (YYYY-MM-DD)" Format(date)
+// @Param body body models.WellDayPlan true "Новые значения показателей"
+// @Param If-Match header string false "ETag, который должна иметь текущая запись, или * для существующей записи"
+// @Param If-None-Match header string false "* — только создать запись, если ее нет"
+// @Success 200 {object} models.WellDayPlan
+// @Header 200 {string} ETag "Версия сохраненной записи"
+// @Success 201 {object} models.WellDayPlan
+// @Header 201 {string} Location "Адрес созданной записи"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /wells/{well}/plans/{date} [put]
+func replaceWellDayPlan(plans repository.WellDayPlanRepository, validator *validation.Validator, w http.ResponseWriter, r *http.Request) {
+	well, date, ok := dayKeyFromPath(w, r)
+	if !ok {
+		return
+	}
+	var plan models.WellDayPlan
+	decodeErrs, err := readRecord(r, &plan, "date_plan")
+	if err != nil {
+		bodyError(w, r, err)
+		return
+	}
+	if (plan.Well != 0 && plan.Well != well) || (!plan.DatePlan.IsZero() && plan.DatePlan != date) {
+		badRequest(w, r, "Well ID or date in body does not match path")
+		return
+	}
+	plan.Well, plan.DatePlan = well, date
+
+	saveWellDayPlan(plans, validator, w, r, plan, decodeErrs, nil)
+}
+
+// @Summary Частичное изменение планового дня за день
+// @Description Изменяет только переданные показатели (JSON Merge Patch: null сбрасывает поле, неизвестные поля отвергаются). Скважину и дату изменить нельзя. С заголовком If-Match запись изменяется, только если ее текущий ETag совпадает с переданным, иначе возвращается 412. Если запись все время изменяют другие запросы, после трех попыток возвращается 409.
+// @Description Запись проверяется правилами валидации; при нарушениях возвращается 422 со списком ошибок по полям.
+// @Description Требуется роль planner или admin.
+// @Tags well_day_plans
+// @Accept json,application/merge-patch+json
+// @Produce json
+// @Param well path int true "ID скважины"
+// @Param date path string true "Дата (YYYY-MM-DD)" Format(date)
+// @Param body body models.WellDayPlan true "Изменяемые показатели"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ"
+// @Param If-Match header string false "ETag, который должна иметь текущая запись"
+// @Success 200 {object} models.WellDayPlan
+// @Header 200 {string} ETag "Версия сохраненной записи"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /wells/{well}/plans/{date} [patch]
+func patchWellDayPlan(plans repository.WellDayPlanRepository, validator *validation.Validator, w http.ResponseWriter, r *http.Request) {
+	well, date, ok := dayKeyFromPath(w, r)
+	if !ok {
+		return
+	}
+	patch, err := readPatch(r)
+	if err != nil {
+		bodyError(w, r, err)
+		return
+	}
+	// Если запись изменили между чтением и сохранением, патч применяется
+	// к новой версии: так параллельные изменения разных полей не теряются.
+	for attempt := 1; ; attempt++ {
+		plan, err := plans.Get(r.Context(), well, date)
+		if err != nil {
+			storeError(w, r, err)
+			return
+		}
+		base := plan
+		decodeErrs, err := applyPatch(&plan, patch, "date_plan")
+		if err != nil {
+			bodyError(w, r, err)
+			return
+		}
+		if plan.Well != well || plan.DatePlan != date {
+			badRequest(w, r, "Well ID and date cannot be changed")
+			return
+		}
+		if saveWellDayPlan(plans, validator, w, r, plan, decodeErrs, &base) || !retryPatch(w, r, attempt) {
+			return
+		}
+	}
+}
+
+// saveWellDayPlan проверяет и сохраняет запись; decodeErrs — ошибки полей,
+// найденные при разборе тела запроса. Если base nil, отсутствующая
+// запись создается. Иначе запись изменяется патчем, примененным к base:
+// для отсутствующей записи возвращается 404, а если сохраненная запись
+// отличается от base, ничего не сохраняется и не отправляется, и функция
+// возвращает false, чтобы патч применили заново. Заголовки If-Match
+// и If-None-Match проверяются по записи, сохраненной в момент изменения.
+func saveWellDayPlan(plans repository.WellDayPlanRepository, validator *validation.Validator, w http.ResponseWriter, r *http.Request, plan models.WellDayPlan, decodeErrs []models.FieldError, base *models.WellDayPlan) bool {
+	errs, err := validator.Plan(r.Context(), plan)
+	if err != nil {
+		storeError(w, r, err)
+		return true
+	}
+	if errs = withDecodeErrors(decodeErrs, errs); len(errs) > 0 {
+		writeValidationError(w, r, errs)
+		return true
+	}
+
+	isNew, err := plans.Put(r.Context(), plan, func(current *models.WellDayPlan) error {
+		etag := ""
+		if current != nil {
+			etag = planETag(*current)
+		} else if base != nil {
+			return repository.ErrNotFound
+		}
+		if err := checkPreconditions(r, etag); err != nil {
+			return err
+		}
+		if base != nil && *current != *base {
+			return errStalePatch
+		}
+		return nil
+	})
+	if errors.Is(err, errStalePatch) {
+		return false
+	}
+	if err != nil {
+		storeError(w, r, err)
+		return true
+	}
+	metrics.RecordsWritten(metrics.Plans, metrics.SourceAPI, 1)
+
+	w.Header().Set("ETag", planETag(plan))
+	if isNew {
+		created(w, fmt.Sprintf("/wells/%d/plans/%s", plan.Well, plan.DatePlan), plan)
+		return true
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+	return true
+}
+
+// planETag возвращает ETag записи для заголовков ETag и If-Match.
+func planETag(plan models.WellDayPlan) string {
+	return dayETag(plan.Debit, plan.EEConsume, plan.Expenses, plan.PumpOperating)
+}
+
+// @Summary Удаление планового дня за день
+// @Description Удаляет планового дня скважины за указанную дату
+// @Description Требуется роль planner или admin.
+// @Tags well_day_plans
+// @Param well path int true "ID скважины"
+// @Param date path string true "Дата (YYYY-MM-DD)" Format(date)
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /wells/{well}/plans/{date} [delete]
+func deleteWellDayPlanByKey(plans repository.WellDayPlanRepository, w http.ResponseWriter, r *http.Request) {
+	well, date, ok := dayKeyFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := plans.Delete(r.Context(), well, date); err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
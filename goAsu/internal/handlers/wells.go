@@ -1,136 +1,412 @@
-package handlers
-
-import (
-	"database/sql"
-	"encoding/json"
-	"goAsu/internal/models"
-	"net/http"
-	"strconv"
-)
-
-func WellsHandler(db *sql.DB) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case "GET":
-			getWells(db, w, r)
-		case "POST":
-			createWell(db, w, r)
-		case "PUT":
-			updateWell(db, w, r)
-		case "DELETE":
-			deleteWell(db, w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	}
-}
-
-// @Summary Получение всех скважин
-// @Description Возвращает все скважины
-// @Tags wells
-// @Produce  json
-// @Success 200 {array} models.Well
-// @Failure 500 {string} string "Internal Server Error"
-// @Router /wells [get]
-func getWells(db *sql.DB, w http.ResponseWriter, r *http.Request) {
-	rows, err := db.Query("SELECT well, ngdu, cdng, kust, mest FROM wells")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
-
-	var wells []models.Well
-	for rows.Next() {
-		var well models.Well
-		if err := rows.Scan(&well.Well, &well.NGDU, &well.CDNG, &well.Kust, &well.Mest); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		wells = append(wells, well)
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(wells)
-}
-
-// @Summary Создание новой скважины
-// @Description Создает новую скважину
-// @Tags wells
-// @Accept  json
-// @Produce  json
-// @Param well body models.Well true "Создаваемая скважина"
-// @Success 201 {string} string "Created"
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
-// @Router /wells [post]
-func createWell(db *sql.DB, w http.ResponseWriter, r *http.Request) {
-	var well models.Well
-	if err := json.NewDecoder(r.Body).Decode(&well); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	sqlStatement := `INSERT INTO wells (well, ngdu, cdng, kust, mest) VALUES ($1, $2, $3, $4, $5)`
-	_, err := db.Exec(sqlStatement, well.Well, well.NGDU, well.CDNG, well.Kust, well.Mest)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(well)
-}
-
-// @Summary Обновление скважины
-// @Description Обновляет информацию о скважине
-// @Tags wells
-// @Accept  json
-// @Produce  json
-// @Param well body models.Well true "Обновляемая скважина"
-// @Success 200 {string} string "OK"
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
-// @Router /wells [put]
-func updateWell(db *sql.DB, w http.ResponseWriter, r *http.Request) {
-	var well models.Well
-	if err := json.NewDecoder(r.Body).Decode(&well); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	sqlStatement := `UPDATE wells SET ngdu=$1, cdng=$2, kust=$3, mest=$4 WHERE well=$5`
-	_, err := db.Exec(sqlStatement, well.NGDU, well.CDNG, well.Kust, well.Mest, well.Well)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(well)
-}
-
-// @Summary Удаление скважины
-// @Description Удаляет скважину по ID
-// @Tags wells
-// @Param id query int true "ID скважины"
-// @Success 200 {string} string "OK"
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
-// @Router /wells [delete]
-func deleteWell(db *sql.DB, w http.ResponseWriter, r *http.Request) {
-	well, err := strconv.Atoi(r.URL.Query().Get("well"))
-	if err != nil {
-		http.Error(w, "Invalid Well ID", http.StatusBadRequest)
-		return
-	}
-
-	sqlStatement := `DELETE FROM wells WHERE well=$1`
-	_, err = db.Exec(sqlStatement, well)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
-}
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"goAsu/internal/civil"
+	"goAsu/internal/models"
+	"goAsu/internal/query"
+	"goAsu/internal/repository"
+	"net/http"
+	"strconv"
+)
+
+var wellsQuery = query.Spec{
+	Filters: []query.FilterSpec{
+		{Param: "well", Field: "well", Op: query.Eq, Kind: query.Int},
+		{Param: "ngdu", Field: "ngdu", Op: query.Eq, Kind: query.Int},
+		{Param: "cdng", Field: "cdng", Op: query.Eq, Kind: query.Int},
+		{Param: "kust", Field: "kust", Op: query.Eq, Kind: query.Int},
+		{Param: "mest", Field: "mest", Op: query.Eq, Kind: query.Int},
+	},
+	Sortable: []string{"well", "ngdu", "cdng", "kust", "mest"},
+	Key:      []string{"well"},
+}
+
+func WellsHandler(wells repository.WellRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			getWells(wells, w, r)
+		case "POST":
+			createWell(wells, w, r)
+		case "PUT":
+			updateWell(wells, w, r)
+		case "DELETE":
+			deleteWell(wells, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+// @Summary Получение всех скважин
+// @Description Возвращает скважины с фильтрацией, сортировкой и постраничным выводом
+// @Tags wells
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/x-ndjson
+// @Param well query []int false "Фильтр по ID скважины" collectionFormat(csv)
+// @Param ngdu query []int false "Фильтр по НГДУ" collectionFormat(csv)
+// @Param cdng query []int false "Фильтр по ЦДНГ" collectionFormat(csv)
+// @Param kust query []int false "Фильтр по кусту" collectionFormat(csv)
+// @Param mest query []int false "Фильтр по месторождению" collectionFormat(csv)
+// @Param sort query string false "Сортировка: well, ngdu, cdng, kust, mest; префикс - означает убывание"
+// @Param limit query int false "Максимальное число записей (по умолчанию 1000, не более 10000; при выгрузке в CSV, XLSX и NDJSON по умолчанию без ограничения)"
+// @Param offset query int false "Число пропускаемых записей"
+// @Param format query string false "Формат ответа: json, csv, xlsx или ndjson; без параметра определяется заголовком Accept" Enums(json, csv, xlsx, ndjson)
+// @Success 200 {array} models.Well
+// @Header 200 {integer} X-Total-Count "Общее число записей, удовлетворяющих фильтрам"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /wells [get]
+func getWells(wells repository.WellRepository, w http.ResponseWriter, r *http.Request) {
+	list, err := query.Parse(wellsQuery, r.URL.Query())
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	format, err := exportFormat(r)
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	if format != "" {
+		writeExport(w, r, format, "wells", func(fn func(models.Well) error) error {
+			return wells.Each(r.Context(), exportAll(list, r), fn)
+		})
+		return
+	}
+
+	page, total, err := wells.List(r.Context(), list)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	setTotalCount(w, total)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// @Summary Создание новой скважины
+// @Description Создает новую скважину
+// @Description Требуется роль admin.
+// @Tags wells
+// @Accept  json
+// @Produce  json
+// @Param well body models.Well true "Создаваемая скважина"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ"
+// @Success 201 {object} models.Well
+// @Header 201 {string} Location "Адрес созданной записи"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /wells [post]
+func createWell(wells repository.WellRepository, w http.ResponseWriter, r *http.Request) {
+	var well models.Well
+	if err := json.NewDecoder(r.Body).Decode(&well); err != nil {
+		bodyError(w, r, err)
+		return
+	}
+
+	if err := wells.Create(r.Context(), well); err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	created(w, "/wells/"+strconv.Itoa(well.Well), well)
+}
+
+// @Summary Обновление скважины
+// @Description Обновляет информацию о скважине
+// @Description Требуется роль admin.
+// @Tags wells
+// @Accept  json
+// @Produce  json
+// @Param well body models.Well true "Обновляемая скважина"
+// @Success 200 {object} models.Well
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /wells [put]
+func updateWell(wells repository.WellRepository, w http.ResponseWriter, r *http.Request) {
+	var well models.Well
+	if err := json.NewDecoder(r.Body).Decode(&well); err != nil {
+		bodyError(w, r, err)
+		return
+	}
+
+	if err := wells.Update(r.Context(), well); err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(well)
+}
+
+// @Summary Удаление скважины
+// @Description Удаляет скважину по ID
+// @Description Требуется роль admin.
+// @Tags wells
+// @Param id query int true "ID скважины"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /wells [delete]
+func deleteWell(wells repository.WellRepository, w http.ResponseWriter, r *http.Request) {
+	well, err := strconv.Atoi(r.URL.Query().Get("well"))
+	if err != nil {
+		badRequest(w, r, "Invalid Well ID")
+		return
+	}
+
+	if err := wells.Delete(r.Context(), well); err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func WellHandler(wells repository.WellRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			getWell(wells, w, r)
+		case "PUT":
+			replaceWell(wells, w, r)
+		case "PATCH":
+			patchWell(wells, w, r)
+		case "DELETE":
+			deleteWellByKey(wells, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+// @Summary Получение скважины
+// @Description Возвращает скважину по ее номеру
+// @Tags wells
+// @Produce json
+// @Param well path int true "ID скважины"
+// @Success 200 {object} models.Well
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /wells/{well} [get]
+func getWell(wells repository.WellRepository, w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt(r, "well")
+	if !ok {
+		badRequest(w, r, "Invalid Well ID")
+		return
+	}
+
+	well, err := wells.Get(r.Context(), id)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(well)
+}
+
+// @Summary Замена скважины
+// @Description Заменяет все поля скважины. Номер скважины берется из пути; если он указан в теле, он должен совпадать.
+// @Description Требуется роль admin.
+// @Tags wells
+// @Accept json
+// @Produce json
+// @Param well path int true "ID скважины"
+// @Param body body models.Well true "Новые значения полей скважины"
+// @Success 200 {object} models.Well
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /wells/{well} [put]
+func replaceWell(wells repository.WellRepository, w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt(r, "well")
+	if !ok {
+		badRequest(w, r, "Invalid Well ID")
+		return
+	}
+	var well models.Well
+	if err := json.NewDecoder(r.Body).Decode(&well); err != nil {
+		bodyError(w, r, err)
+		return
+	}
+	if well.Well != 0 && well.Well != id {
+		badRequest(w, r, "Well ID in body does not match path")
+		return
+	}
+	well.Well = id
+
+	if err := wells.Update(r.Context(), well); err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(well)
+}
+
+// @Summary Частичное изменение скважины
+// @Description Изменяет только переданные поля скважины (JSON Merge Patch: null сбрасывает поле, неизвестные поля отвергаются). Номер скважины изменить нельзя.
+// @Description Требуется роль admin.
+// @Tags wells
+// @Accept json,application/merge-patch+json
+// @Produce json
+// @Param well path int true "ID скважины"
+// @Param body body models.Well true "Изменяемые поля скважины"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ"
+// @Success 200 {object} models.Well
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /wells/{well} [patch]
+func patchWell(wells repository.WellRepository, w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt(r, "well")
+	if !ok {
+		badRequest(w, r, "Invalid Well ID")
+		return
+	}
+	patch, err := readPatch(r)
+	if err != nil {
+		bodyError(w, r, err)
+		return
+	}
+	var patchErr error
+	well, err := wells.Patch(r.Context(), id, func(well *models.Well) error {
+		if _, patchErr = applyPatch(well, patch, ""); patchErr == nil && well.Well != id {
+			patchErr = errors.New("Well ID cannot be changed")
+		}
+		return patchErr
+	})
+	if patchErr != nil {
+		bodyError(w, r, patchErr)
+		return
+	}
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(well)
+}
+
+// @Summary Удаление скважины по номеру
+// @Description Удаляет скважину. Скважину, по которой есть история или планы, удалить нельзя.
+// @Description Требуется роль admin.
+// @Tags wells
+// @Param well path int true "ID скважины"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /wells/{well} [delete]
+func deleteWellByKey(wells repository.WellRepository, w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt(r, "well")
+	if !ok {
+		badRequest(w, r, "Invalid Well ID")
+		return
+	}
+
+	if err := wells.Delete(r.Context(), id); err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func WellCardHandler(reports repository.ReportRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			getWellCard(reports, w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	}
+}
+
+// @Summary Карточка скважины
+// @Description Возвращает скважину с названиями и типами объектов иерархии (НГДУ, ЦДНГ, куст, месторождение),
+// @Description последние фактические данные, план на текущий день и суммарные показатели за 30 дней по текущий день включительно.
+// @Description Параметр date заменяет текущий день другой датой.
+// @Tags wells
+// @Produce json
+// @Param well path int true "ID скважины"
+// @Param date query string false "Дата, на которую строится карточка (YYYY-MM-DD); по умолчанию текущий день" Format(date)
+// @Success 200 {object} models.WellCard
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /wells/{well}/card [get]
+func getWellCard(reports repository.ReportRepository, w http.ResponseWriter, r *http.Request) {
+	well, ok := pathInt(r, "well")
+	if !ok {
+		badRequest(w, r, "Invalid Well ID")
+		return
+	}
+	date := civil.Today()
+	if raw := r.URL.Query().Get("date"); raw != "" {
+		var err error
+		if date, err = civil.Parse(raw); err != nil {
+			badRequest(w, r, "Invalid Date")
+			return
+		}
+	}
+
+	card, err := reports.WellCard(r.Context(), well, date)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(card)
+}
@@ -0,0 +1,228 @@
+// Package idempotency позволяет безопасно повторять запросы POST и PATCH.
+//
+// Клиент передает в заголовке Idempotency-Key уникальное значение для каждой
+// логической операции и повторяет запрос с тем же значением после сбоя сети
+// или тайм-аута. Первый запрос выполняется, его ответ запоминается, а повторы
+// получают сохраненный ответ с заголовком Idempotent-Replayed: true, не изменяя
+// данные повторно. Ключ действует в пределах клиента, метода и пути запроса.
+// Ответы хранятся в памяти процесса в течение заданного срока.
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"goAsu/internal/apierror"
+	"goAsu/internal/auth"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// Header — заголовок с ключом идемпотентности.
+	Header = "Idempotency-Key"
+	// ReplayedHeader отмечает ответ, возвращенный из сохраненных.
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+// maxKeyLength ограничивает длину ключа.
+const maxKeyLength = 255
+
+// maxBodySize ограничивает размер тела запроса, которое читается
+// для сравнения повторного запроса с первым.
+const maxBodySize = 32 << 20
+
+// replayedHeaders — заголовки ответа, которые воспроизводятся при повторе.
+var replayedHeaders = []string{"Content-Type", "Location", "X-Total-Count"}
+
+type entry struct {
+	fingerprint [sha256.Size]byte
+	// done закрывается, когда ответ сохранен или запрос завершился ошибкой.
+	done    chan struct{}
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// Store хранит ответы на запросы с ключом идемпотентности.
+type Store struct {
+	ttl       time.Duration
+	mu        sync.Mutex
+	entries   map[[sha256.Size]byte]*entry
+	lastSweep time.Time
+}
+
+// New создает Store, хранящий ответы в течение ttl.
+func New(ttl time.Duration) *Store {
+	return &Store{ttl: ttl, entries: map[[sha256.Size]byte]*entry{}}
+}
+
+// Middleware обрабатывает заголовок Idempotency-Key у запросов POST и PATCH.
+// Запросы без заголовка и запросы с другими методами передаются next без изменений.
+// Ключи разделяются по клиенту из auth.FromContext, поэтому Middleware
+// должен выполняться внутри auth.Authenticator.Protect. Повтор с новым
+// токеном того же клиента получает сохраненный ответ.
+//
+// Повтор с тем же ключом, но другим телом или параметрами отклоняется с кодом 422,
+// повтор во время выполнения первого запроса — с кодом 409. Ответы со статусом
+// 5xx, 401, 403 и ответы на отмененные клиентом запросы не сохраняются:
+// такой запрос можно повторить с тем же ключом.
+func (s *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPatch) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxKeyLength {
+			apierror.Write(w, r, http.StatusBadRequest, apierror.BadRequest, "Invalid Idempotency-Key: must be at most 255 characters", nil)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+		var tooLarge *http.MaxBytesError
+		if err != nil && !errors.As(err, &tooLarge) {
+			apierror.Write(w, r, http.StatusBadRequest, apierror.BadRequest, err.Error(), nil)
+			return
+		}
+		if err != nil || len(body) > maxBodySize {
+			apierror.Write(w, r, http.StatusRequestEntityTooLarge, apierror.PayloadTooLarge, "Request body is too large", nil)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		principal, _ := auth.FromContext(r.Context())
+		scope := hash(principal.Name, r.Method, r.URL.Path, key)
+		fingerprint := hash(r.URL.RawQuery, r.Header.Get("Content-Type"), string(body))
+
+		e, owner := s.acquire(scope, fingerprint)
+		if !owner {
+			if e.fingerprint != fingerprint {
+				apierror.Write(w, r, http.StatusUnprocessableEntity, apierror.IdempotencyKeyReused, "Idempotency-Key was used with a different request", nil)
+				return
+			}
+			select {
+			case <-e.done:
+			default:
+				apierror.Write(w, r, http.StatusConflict, apierror.IdempotencyInProgress, "A request with this Idempotency-Key is in progress", nil)
+				return
+			}
+			for name, values := range e.header {
+				w.Header()[name] = values
+			}
+			w.Header().Set(ReplayedHeader, "true")
+			w.WriteHeader(e.status)
+			w.Write(e.body)
+			return
+		}
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			if v := recover(); v != nil {
+				s.release(scope, e)
+				panic(v)
+			}
+			if rec.status >= 500 || rec.status == http.StatusUnauthorized || rec.status == http.StatusForbidden ||
+				rec.status == apierror.StatusClientClosedRequest {
+				s.release(scope, e)
+				return
+			}
+			s.complete(e, rec)
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// acquire возвращает запись для ключа scope. owner равен true, если запись
+// создана этим вызовом и запрос должен быть выполнен.
+func (s *Store) acquire(scope, fingerprint [sha256.Size]byte) (e *entry, owner bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+	if e, ok := s.entries[scope]; ok && (e.expires.IsZero() || now.Before(e.expires)) {
+		return e, false
+	}
+	e = &entry{fingerprint: fingerprint, done: make(chan struct{})}
+	s.entries[scope] = e
+	return e, true
+}
+
+func (s *Store) complete(e *entry, rec *recorder) {
+	header := http.Header{}
+	for _, name := range replayedHeaders {
+		if values := rec.Header().Values(name); len(values) > 0 {
+			header[name] = values
+		}
+	}
+
+	s.mu.Lock()
+	e.status, e.header, e.body = rec.status, header, rec.body.Bytes()
+	e.expires = time.Now().Add(s.ttl)
+	s.mu.Unlock()
+	close(e.done)
+}
+
+// release удаляет запись, чтобы запрос можно было повторить с тем же ключом.
+func (s *Store) release(scope [sha256.Size]byte, e *entry) {
+	s.mu.Lock()
+	if s.entries[scope] == e {
+		delete(s.entries, scope)
+	}
+	s.mu.Unlock()
+	close(e.done)
+}
+
+// sweep удаляет просроченные записи не чаще раза в минуту.
+func (s *Store) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for scope, e := range s.entries {
+		if !e.expires.IsZero() && now.After(e.expires) {
+			delete(s.entries, scope)
+		}
+	}
+}
+
+func hash(parts ...string) [sha256.Size]byte {
+	h := sha256.New()
+	for _, part := range parts {
+		io.WriteString(h, part)
+		h.Write([]byte{0})
+	}
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// recorder передает ответ клиенту и одновременно запоминает его.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+// Unwrap возвращает исходный ResponseWriter для http.ResponseController.
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,165 @@
+package idempotency
+
+import (
+	"encoding/json"
+	"fmt"
+	"goAsu/internal/apierror"
+	"goAsu/internal/auth"
+	"goAsu/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// request описывает запрос к обработчику под Middleware.
+type request struct {
+	principal string
+	method    string
+	path      string
+	key       string
+	body      string
+}
+
+// counter отвечает числом выполненных запросов и статусом из очереди
+// statuses (201, когда очередь пуста).
+type counter struct {
+	calls    int
+	statuses []int
+}
+
+func (c *counter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.calls++
+	status := http.StatusCreated
+	if len(c.statuses) > 0 {
+		status, c.statuses = c.statuses[0], c.statuses[1:]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/items/1")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"calls":%d}`, c.calls)
+}
+
+func do(t *testing.T, h http.Handler, req request) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(req.method, req.path, strings.NewReader(req.body))
+	r.Header.Set("Content-Type", "application/json")
+	if req.key != "" {
+		r.Header.Set(Header, req.key)
+	}
+	r = r.WithContext(auth.WithPrincipal(r.Context(), auth.Principal{Name: req.principal}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestMiddleware(t *testing.T) {
+	first := request{"scada", "POST", "/items", "key-1", `{"a":1}`}
+	tests := []struct {
+		name     string
+		statuses []int
+		second   request
+		status   int
+		code     string
+		replayed bool
+		calls    int
+	}{
+		{"replay", nil, first, http.StatusCreated, "", true, 1},
+		{"different body", nil, request{"scada", "POST", "/items", "key-1", `{"a":2}`}, http.StatusUnprocessableEntity, apierror.IdempotencyKeyReused, false, 1},
+		{"different query", nil, request{"scada", "POST", "/items?mode=best_effort", "key-1", `{"a":1}`}, http.StatusUnprocessableEntity, apierror.IdempotencyKeyReused, false, 1},
+		{"other key", nil, request{"scada", "POST", "/items", "key-2", `{"a":1}`}, http.StatusCreated, "", false, 2},
+		{"other principal", nil, request{"etl", "POST", "/items", "key-1", `{"a":1}`}, http.StatusCreated, "", false, 2},
+		{"other path", nil, request{"scada", "POST", "/other", "key-1", `{"a":1}`}, http.StatusCreated, "", false, 2},
+		{"no key", nil, request{"scada", "POST", "/items", "", `{"a":1}`}, http.StatusCreated, "", false, 2},
+		{"server error is not stored", []int{http.StatusInternalServerError}, first, http.StatusCreated, "", false, 2},
+		{"client error is stored", []int{http.StatusBadRequest}, first, http.StatusBadRequest, "", true, 1},
+		{"canceled request is not stored", []int{apierror.StatusClientClosedRequest}, first, http.StatusCreated, "", false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &counter{statuses: tt.statuses}
+			h := New(time.Hour).Middleware(next)
+			do(t, h, first)
+			rec := do(t, h, tt.second)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.code != "" {
+				var resp models.ErrorResponse
+				if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+					t.Fatal(err)
+				}
+				if resp.Code != tt.code {
+					t.Errorf("code = %q, want %q", resp.Code, tt.code)
+				}
+			}
+			if replayed := rec.Header().Get(ReplayedHeader) == "true"; replayed != tt.replayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.replayed)
+			}
+			if tt.replayed {
+				if body := rec.Body.String(); body != `{"calls":1}` {
+					t.Errorf("replayed body = %s, want the first response", body)
+				}
+				if location := rec.Header().Get("Location"); location != "/items/1" {
+					t.Errorf("replayed Location = %q, want %q", location, "/items/1")
+				}
+			}
+			if next.calls != tt.calls {
+				t.Errorf("handler called %d times, want %d", next.calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestMiddlewareIgnoresOtherMethods(t *testing.T) {
+	next := &counter{}
+	h := New(time.Hour).Middleware(next)
+	for i := 0; i < 2; i++ {
+		if rec := do(t, h, request{"scada", "PUT", "/items/1", "key-1", `{}`}); rec.Header().Get(ReplayedHeader) != "" {
+			t.Fatal("PUT response was replayed")
+		}
+	}
+	if next.calls != 2 {
+		t.Errorf("handler called %d times, want 2", next.calls)
+	}
+}
+
+func TestMiddlewareInProgress(t *testing.T) {
+	started, finish := make(chan struct{}), make(chan struct{})
+	h := New(time.Hour).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-finish
+		w.WriteHeader(http.StatusCreated)
+	}))
+	req := request{"scada", "POST", "/items", "key-1", `{}`}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		do(t, h, req)
+	}()
+	<-started
+	rec := do(t, h, req)
+	close(finish)
+	<-done
+	if rec.Code != http.StatusConflict {
+		t.Errorf("status = %d, want %d: %s", rec.Code, http.StatusConflict, rec.Body)
+	}
+}
+
+func TestMiddlewareKeyTooLong(t *testing.T) {
+	h := New(time.Hour).Middleware(&counter{})
+	rec := do(t, h, request{"scada", "POST", "/items", strings.Repeat("k", maxKeyLength+1), `{}`})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestRecorderUnwrap(t *testing.T) {
+	h := New(time.Hour).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush() = %v", err)
+		}
+	}))
+	do(t, h, request{"scada", "POST", "/items", "key-1", `{}`})
+}
@@ -0,0 +1,276 @@
+// Package importer загружает дневные данные скважин из файлов CSV и XLSX.
+//
+// Первая строка файла — заголовок с именами столбцов, совпадающими с JSON-тегами
+// моделей: well, date_fact (или date_plan), debit, ee_consume, expenses,
+// pump_operating. Порядок столбцов произвольный, лишние столбцы игнорируются.
+// Все строки проверяются до записи, в том числе правилами пакета validation;
+// если найдена хотя бы одна ошибка, данные не сохраняются. Записи с существующим ключом (well, дата) заменяются.
+package importer
+
+import (
+	"context"
+	"fmt"
+	"goAsu/internal/civil"
+	"goAsu/internal/models"
+	"goAsu/internal/repository"
+	"goAsu/internal/validation"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	CSV  = "csv"
+	XLSX = "xlsx"
+)
+
+var contentTypes = map[string]string{
+	"text/csv":                 CSV,
+	"application/csv":          CSV,
+	"application/vnd.ms-excel": CSV,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": XLSX,
+}
+
+// FormatFromContentType определяет формат файла по заголовку Content-Type.
+func FormatFromContentType(contentType string) (string, bool) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	format, ok := contentTypes[strings.TrimSpace(strings.ToLower(mediaType))]
+	return format, ok
+}
+
+// FormatFromName определяет формат файла по расширению имени.
+func FormatFromName(name string) (string, bool) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return CSV, true
+	case ".xlsx":
+		return XLSX, true
+	}
+	return "", false
+}
+
+// Histories разбирает файл с историей скважин за день и, если ошибок нет
+// и dryRun не задан, сохраняет записи в одной транзакции.
+// Ошибка возвращается только при сбое чтения или записи хранилища.
+func Histories(ctx context.Context, histories repository.WellDayHistoryRepository, validator *validation.Validator, format string, r io.Reader, dryRun bool) (models.ImportResult, error) {
+	days, result := decode(format, r, "date_fact", dryRun)
+	if len(result.Errors) > 0 {
+		return result, nil
+	}
+
+	records := make([]models.WellDayHistory, len(days))
+	for i, d := range days {
+		records[i] = models.WellDayHistory{
+			Well: d.well, DateFact: d.date,
+			Debit: d.debit, EEConsume: d.eeConsume, Expenses: d.expenses, PumpOperating: d.pumpOperating,
+		}
+	}
+	checks, err := validator.Histories(ctx, records)
+	if err != nil {
+		return result, err
+	}
+	addFieldErrors(&result, days, checks)
+	if len(result.Errors) > 0 || dryRun {
+		return result, nil
+	}
+	if err := histories.Upsert(ctx, records); err != nil {
+		return result, err
+	}
+	result.Imported = len(records)
+	return result, nil
+}
+
+// Plans разбирает файл с планами скважин за день и, если ошибок нет
+// и dryRun не задан, сохраняет записи в одной транзакции.
+// Ошибка возвращается только при сбое чтения или записи хранилища.
+func Plans(ctx context.Context, plans repository.WellDayPlanRepository, validator *validation.Validator, format string, r io.Reader, dryRun bool) (models.ImportResult, error) {
+	days, result := decode(format, r, "date_plan", dryRun)
+	if len(result.Errors) > 0 {
+		return result, nil
+	}
+
+	records := make([]models.WellDayPlan, len(days))
+	for i, d := range days {
+		records[i] = models.WellDayPlan{
+			Well: d.well, DatePlan: d.date,
+			Debit: d.debit, EEConsume: d.eeConsume, Expenses: d.expenses, PumpOperating: d.pumpOperating,
+		}
+	}
+	checks, err := validator.Plans(ctx, records)
+	if err != nil {
+		return result, err
+	}
+	addFieldErrors(&result, days, checks)
+	if len(result.Errors) > 0 || dryRun {
+		return result, nil
+	}
+	if err := plans.Upsert(ctx, records); err != nil {
+		return result, err
+	}
+	result.Imported = len(records)
+	return result, nil
+}
+
+// day — разобранная строка файла, общая для истории и планов.
+type day struct {
+	row           int
+	well          int
+	date          civil.Date
+	debit         float64
+	eeConsume     float64
+	expenses      float64
+	pumpOperating float64
+}
+
+func decode(format string, r io.Reader, dateColumn string, dryRun bool) ([]day, models.ImportResult) {
+	result := models.ImportResult{DryRun: dryRun, Errors: []models.ImportRowError{}}
+
+	var rows [][]string
+	var err error
+	switch format {
+	case CSV:
+		rows, err = readCSV(r)
+	case XLSX:
+		rows, err = readXLSX(r)
+	default:
+		err = fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		result.Errors = append(result.Errors, rowError(err))
+		return nil, result
+	}
+	if len(rows) == 0 {
+		result.Errors = append(result.Errors, models.ImportRowError{Row: 1, Message: "file is empty"})
+		return nil, result
+	}
+
+	columns := []string{"well", dateColumn, "debit", "ee_consume", "expenses", "pump_operating"}
+	index := map[string]int{}
+	for i, name := range rows[0] {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range columns {
+		if _, ok := index[name]; !ok {
+			result.Errors = append(result.Errors, models.ImportRowError{Row: 1, Column: name, Message: "column is missing"})
+		}
+	}
+	if len(result.Errors) > 0 {
+		return nil, result
+	}
+
+	var days []day
+	seen := map[string]int{}
+	for i, row := range rows[1:] {
+		rowNum := i + 2
+		if isBlank(row) {
+			continue
+		}
+		result.Rows++
+
+		cell := func(name string) string {
+			if j := index[name]; j < len(row) {
+				return strings.TrimSpace(row[j])
+			}
+			return ""
+		}
+		d := day{row: rowNum}
+		var rowErrors []models.ImportRowError
+		fail := func(column, message string) {
+			rowErrors = append(rowErrors, models.ImportRowError{Row: rowNum, Column: column, Message: message})
+		}
+
+		well, err := strconv.Atoi(cell("well"))
+		if err != nil || well <= 0 {
+			fail("well", "must be a positive integer")
+		}
+		d.well = well
+
+		if raw := cell(dateColumn); raw == "" {
+			fail(dateColumn, "is required")
+		} else if d.date, err = parseDate(raw, format == XLSX); err != nil {
+			fail(dateColumn, "must be a date in YYYY-MM-DD format")
+		}
+
+		for _, metric := range []struct {
+			name string
+			dst  *float64
+		}{
+			{"debit", &d.debit},
+			{"ee_consume", &d.eeConsume},
+			{"expenses", &d.expenses},
+			{"pump_operating", &d.pumpOperating},
+		} {
+			value, err := strconv.ParseFloat(strings.Replace(cell(metric.name), ",", ".", 1), 64)
+			switch {
+			case err != nil:
+				fail(metric.name, "must be a number")
+			// ParseFloat принимает NaN и Inf, которые нельзя передать в JSON.
+			case math.IsNaN(value) || math.IsInf(value, 0):
+				fail(metric.name, "must be a finite number")
+			}
+			*metric.dst = value
+		}
+
+		if len(rowErrors) == 0 {
+			key := fmt.Sprintf("%d/%s", d.well, d.date)
+			if first, ok := seen[key]; ok {
+				fail("", fmt.Sprintf("duplicates row %d for well %d on %s", first, d.well, d.date))
+			} else {
+				seen[key] = rowNum
+			}
+		}
+		if len(rowErrors) > 0 {
+			result.Errors = append(result.Errors, rowErrors...)
+			continue
+		}
+		days = append(days, d)
+	}
+	return days, result
+}
+
+// addFieldErrors переносит ошибки проверки записей в результат загрузки
+// с номерами строк файла.
+func addFieldErrors(result *models.ImportResult, days []day, checks [][]models.FieldError) {
+	for i, errs := range checks {
+		for _, e := range errs {
+			result.Errors = append(result.Errors, models.ImportRowError{Row: days[i].row, Column: e.Field, Message: e.Message})
+		}
+	}
+}
+
+// maxExcelSerial — порядковый номер дня Excel для 9999-12-31, последней
+// даты, которую допускает Excel.
+const maxExcelSerial = 2958465
+
+// parseDate принимает дату в формате YYYY-MM-DD, а если serials задан, также
+// порядковый номер дня Excel, в котором XLSX хранит даты. В CSV номера дней
+// не принимаются: там число в столбце даты — скорее всего ошибка.
+func parseDate(value string, serials bool) (civil.Date, error) {
+	if date, err := civil.Parse(value); err == nil {
+		return date, nil
+	}
+	if !serials {
+		return civil.Date{}, fmt.Errorf("invalid date %q", value)
+	}
+	if serial, err := strconv.ParseFloat(value, 64); err == nil && serial >= 1 && serial < maxExcelSerial+1 {
+		return excelDate(serial), nil
+	}
+	return civil.Date{}, fmt.Errorf("invalid date %q", value)
+}
+
+// excelDate переводит порядковый номер дня Excel (система дат 1900) в дату.
+func excelDate(serial float64) civil.Date {
+	return civil.Date{Year: 1899, Month: time.December, Day: 30}.AddDays(int(serial))
+}
+
+func isBlank(row []string) bool {
+	for _, value := range row {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,208 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"goAsu/internal/civil"
+	"goAsu/internal/config"
+	"goAsu/internal/models"
+	"goAsu/internal/repository/memory"
+	"goAsu/internal/validation"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxFile возвращает книгу XLSX, первый лист которой содержит rows.
+func xlsxFile(t *testing.T, rows ...[]interface{}) []byte {
+	t.Helper()
+	book := excelize.NewFile()
+	defer book.Close()
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := book.SetSheetRow("Sheet1", cell, &row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf, err := book.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestHistories(t *testing.T) {
+	const header = "well,date_fact,debit,ee_consume,expenses,pump_operating\n"
+	xlsxHeader := []interface{}{"well", "date_fact", "debit", "ee_consume", "expenses", "pump_operating"}
+	tests := []struct {
+		name     string
+		format   string
+		file     []byte
+		dryRun   bool
+		imported int
+		errors   []models.ImportRowError
+	}{
+		{
+			name:     "csv",
+			format:   CSV,
+			file:     []byte(header + "4455,2024-12-07,10,20,3.5,24\n4456,2024-12-07,11,21,4,12\n"),
+			imported: 2,
+		},
+		{
+			name:     "csv with bom and semicolons",
+			format:   CSV,
+			file:     []byte("\xef\xbb\xbfwell;date_fact;debit;ee_consume;expenses;pump_operating\n4455;2024-12-07;10,5;20;3,5;24\n"),
+			imported: 1,
+		},
+		{
+			name:     "csv with a header longer than the read buffer",
+			format:   CSV,
+			file:     []byte("comment_" + strings.Repeat("x", 5000) + ";well;date_fact;debit;ee_consume;expenses;pump_operating\n;4455;2024-12-07;10;20;3,5;24\n"),
+			imported: 1,
+		},
+		{
+			name:   "dry run",
+			format: CSV,
+			file:   []byte(header + "4455,2024-12-07,10,20,3.5,24\n"),
+			dryRun: true,
+		},
+		{
+			name:   "csv rejects excel serial dates",
+			format: CSV,
+			file:   []byte(header + "4455,45633,10,20,3.5,24\n"),
+			errors: []models.ImportRowError{{Row: 2, Column: "date_fact", Message: "must be a date in YYYY-MM-DD format"}},
+		},
+		{
+			name:   "row errors",
+			format: CSV,
+			file:   []byte(header + "4455,2024-12-07,10,20,3.5,24\nabc,,x,20,NaN,24\n,,,,,\n4455,2024-12-07,1,1,1,1\n"),
+			errors: []models.ImportRowError{
+				{Row: 3, Column: "well", Message: "must be a positive integer"},
+				{Row: 3, Column: "date_fact", Message: "is required"},
+				{Row: 3, Column: "debit", Message: "must be a number"},
+				{Row: 3, Column: "expenses", Message: "must be a finite number"},
+				{Row: 5, Message: "duplicates row 2 for well 4455 on 2024-12-07"},
+			},
+		},
+		{
+			name:   "validation errors",
+			format: CSV,
+			file:   []byte(header + "4455,2024-12-07,10,20,3.5,25\n99,2024-12-07,10,20,3.5,24\n"),
+			errors: []models.ImportRowError{
+				{Row: 2, Column: "pump_operating", Message: "must not exceed 24 hours"},
+				{Row: 3, Column: "well", Message: "well 99 does not exist"},
+			},
+		},
+		{
+			name:   "missing column",
+			format: CSV,
+			file:   []byte("well,date_fact,debit,ee_consume,expenses\n4455,2024-12-07,10,20,3.5\n"),
+			errors: []models.ImportRowError{{Row: 1, Column: "pump_operating", Message: "column is missing"}},
+		},
+		{
+			name:   "csv syntax error",
+			format: CSV,
+			file:   []byte(header + "4455,2024-12-07,10,20,3.5,24\n4456,2024-12-07,1\"0,20,3.5,24\n"),
+			errors: []models.ImportRowError{{Row: 3, Message: `bare " in non-quoted-field`}},
+		},
+		{
+			name:   "empty file",
+			format: CSV,
+			file:   nil,
+			errors: []models.ImportRowError{{Row: 1, Message: "file is empty"}},
+		},
+		{
+			name:   "xlsx",
+			format: XLSX,
+			file: xlsxFile(t, xlsxHeader,
+				[]interface{}{4455, 45633, 10, 20, 3.5, 24},
+				[]interface{}{4456, "2024-12-07", 11, 21, 4, 12},
+			),
+			imported: 2,
+		},
+		{
+			name:   "xlsx row errors",
+			format: XLSX,
+			file:   xlsxFile(t, xlsxHeader, []interface{}{4455, "07.12.2024", "x", 20, 3.5, 24}),
+			errors: []models.ImportRowError{
+				{Row: 2, Column: "date_fact", Message: "must be a date in YYYY-MM-DD format"},
+				{Row: 2, Column: "debit", Message: "must be a number"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.NewDemo()
+			validator := validation.New(config.ValidationConfig{}, store.Wells)
+			result, err := Histories(ctx, store.Histories, validator, tt.format, bytes.NewReader(tt.file), tt.dryRun)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.errors == nil {
+				tt.errors = []models.ImportRowError{}
+			}
+			if !reflect.DeepEqual(result.Errors, tt.errors) {
+				t.Errorf("errors = %+v, want %+v", result.Errors, tt.errors)
+			}
+			if result.Imported != tt.imported {
+				t.Errorf("imported = %d, want %d", result.Imported, tt.imported)
+			}
+			date := civil.Date{Year: 2024, Month: time.December, Day: 7}
+			_, err = store.Histories.Get(ctx, 4455, date)
+			if saved := err == nil; saved != (tt.imported > 0) {
+				t.Errorf("history of 4455 on %s saved = %v, want %v", date, saved, tt.imported > 0)
+			}
+		})
+	}
+}
+
+func TestPlansCSV(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewDemo()
+	validator := validation.New(config.ValidationConfig{}, store.Wells)
+	file := "well,date_plan,debit,ee_consume,expenses,pump_operating\n4455,2025-01-15,10,20,3.5,24\n"
+	result, err := Plans(ctx, store.Plans, validator, CSV, strings.NewReader(file), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Errors) > 0 || result.Imported != 1 {
+		t.Fatalf("result = %+v, want 1 plan imported", result)
+	}
+	if _, err := store.Plans.Get(ctx, 4455, civil.Date{Year: 2025, Month: time.January, Day: 15}); err != nil {
+		t.Errorf("plan not saved: %v", err)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		value   string
+		serials bool
+		want    civil.Date
+		wantErr bool
+	}{
+		{"2024-12-07", false, civil.Date{Year: 2024, Month: time.December, Day: 7}, false},
+		{"2024-12-07", true, civil.Date{Year: 2024, Month: time.December, Day: 7}, false},
+		{"45633", true, civil.Date{Year: 2024, Month: time.December, Day: 7}, false},
+		{"45633.75", true, civil.Date{Year: 2024, Month: time.December, Day: 7}, false},
+		{"2958465", true, civil.Date{Year: 9999, Month: time.December, Day: 31}, false},
+		{"45633", false, civil.Date{}, true},
+		{"0", true, civil.Date{}, true},
+		{"-1", true, civil.Date{}, true},
+		{"2958466", true, civil.Date{}, true},
+		{"1e300", true, civil.Date{}, true},
+		{"07.12.2024", true, civil.Date{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseDate(tt.value, tt.serials)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseDate(%q, %v) = %v, %v; want %v, error %v", tt.value, tt.serials, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"goAsu/internal/models"
+	"io"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// readCSV читает CSV с разделителем "," или ";" (последний используется
+// в выгрузках Excel с русской локалью). Разделитель определяется по заголовку.
+func readCSV(r io.Reader) ([][]string, error) {
+	br := bufio.NewReader(r)
+	if bom, err := br.Peek(3); err == nil && bytes.Equal(bom, []byte("\xef\xbb\xbf")) {
+		br.Discard(3)
+	}
+
+	// Заголовок читается целиком, каким бы длинным он ни был, и затем
+	// возвращается перед остатком файла.
+	header, err := br.ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	reader := csv.NewReader(io.MultiReader(bytes.NewReader(header), br))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		reader.Comma = ';'
+	}
+	return reader.ReadAll()
+}
+
+// readXLSX читает первый лист книги. Значения читаются без форматирования,
+// поэтому числа и даты приходят в исходном виде.
+func readXLSX(r io.Reader) ([][]string, error) {
+	book, err := excelize.OpenReader(r, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, err
+	}
+	defer book.Close()
+
+	sheet := book.GetSheetName(0)
+	if sheet == "" {
+		return nil, errors.New("workbook has no sheets")
+	}
+	return book.GetRows(sheet, excelize.Options{RawCellValue: true})
+}
+
+// rowError описывает ошибку чтения файла. Для ошибок разбора CSV
+// указывается номер строки.
+func rowError(err error) models.ImportRowError {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return models.ImportRowError{Row: parseErr.Line, Message: parseErr.Err.Error()}
+	}
+	return models.ImportRowError{Message: err.Error()}
+}
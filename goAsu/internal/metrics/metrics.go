@@ -0,0 +1,97 @@
+// Package metrics собирает метрики сервера в формате Prometheus: длительность
+// и число HTTP-запросов по маршрутам, состояние пула соединений с базой
+// данных, число записанных дневных записей и прерванных обращений к базе.
+package metrics
+
+import (
+	"database/sql"
+	"goAsu/internal/middleware"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "goasu"
+
+var (
+	registry = prometheus.NewRegistry()
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Длительность обработки HTTP-запросов по маршрутам.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	recordsWritten = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "records_written_total",
+		Help:      "Число записанных дневных записей по ресурсам и источникам (api, import).",
+	}, []string{"resource", "source"})
+
+	queriesInterrupted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_queries_interrupted_total",
+		Help:      "Число обращений к базе данных, прерванных отменой запроса клиентом (canceled) или по тайм-ауту (timeout).",
+	}, []string{"reason"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestDuration,
+		recordsWritten,
+		queriesInterrupted,
+	)
+}
+
+// Ресурсы, для которых считаются записанные записи.
+const (
+	Histories = "well_day_histories"
+	Plans     = "well_day_plans"
+)
+
+// Источники записанных записей.
+const (
+	SourceAPI    = "api"
+	SourceImport = "import"
+)
+
+// RecordsWritten увеличивает число записей resource, записанных из source.
+func RecordsWritten(resource, source string, n int) {
+	if n > 0 {
+		recordsWritten.WithLabelValues(resource, source).Add(float64(n))
+	}
+}
+
+// QueryInterrupted отмечает обращение к базе данных, прерванное по причине
+// reason: canceled или timeout.
+func QueryInterrupted(reason string) {
+	queriesInterrupted.WithLabelValues(reason).Inc()
+}
+
+// RegisterDB добавляет метрики пула соединений db (sql.DBStats).
+func RegisterDB(db *sql.DB) {
+	registry.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+}
+
+// Handler отдает метрики в текстовом формате Prometheus.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Middleware измеряет длительность запросов по маршрутам routes.
+func Middleware(routes *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := middleware.NewStatusRecorder(w)
+		next.ServeHTTP(rec, r)
+		requestDuration.WithLabelValues(r.Method, middleware.Route(routes, r), strconv.Itoa(rec.Status)).
+			Observe(time.Since(start).Seconds())
+	})
+}
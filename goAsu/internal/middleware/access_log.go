@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type userKey struct{}
+
+// SetUser сообщает журналу запросов имя клиента, прошедшего проверку
+// подлинности. Вызов без AccessLog ничего не делает.
+func SetUser(ctx context.Context, name string) {
+	if user, ok := ctx.Value(userKey{}).(*string); ok {
+		*user = name
+	}
+}
+
+// Route возвращает шаблон маршрута routes, которому соответствует запрос,
+// например "/wells/{well}". Для запросов без маршрута возвращается "unmatched",
+// чтобы произвольные пути не попадали в журналы и метрики как отдельные маршруты.
+func Route(routes *http.ServeMux, r *http.Request) string {
+	if _, pattern := routes.Handler(r); pattern != "" {
+		return pattern
+	}
+	return "unmatched"
+}
+
+// AccessLog записывает в logger по строке на каждый запрос: метод, маршрут
+// routes, путь, статус, длительность, размер ответа, идентификатор запроса,
+// имя клиента и идентификатор трассировки, если запрос трассируется. Запросы с ответом 5xx записываются с уровнем ERROR,
+// 4xx — WARN, остальные — INFO.
+func AccessLog(logger *slog.Logger, routes *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		var user string
+		rec := NewStatusRecorder(w)
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), userKey{}, &user)))
+
+		level := slog.LevelInfo
+		switch {
+		case rec.Status >= 500:
+			level = slog.LevelError
+		case rec.Status >= 400:
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("route", Route(routes, r)),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.Status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int64("bytes", rec.Bytes),
+			slog.String("request_id", RequestIDFromContext(r.Context())),
+			slog.String("user", user),
+		}
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			attrs = append(attrs, slog.String("trace_id", sc.TraceID().String()))
+		}
+		logger.LogAttrs(r.Context(), level, "request", attrs...)
+	})
+}
+
+// StatusRecorder запоминает статус и размер ответа.
+type StatusRecorder struct {
+	http.ResponseWriter
+	Status      int
+	Bytes       int64
+	wroteHeader bool
+}
+
+func NewStatusRecorder(w http.ResponseWriter) *StatusRecorder {
+	return &StatusRecorder{ResponseWriter: w, Status: http.StatusOK}
+}
+
+func (r *StatusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.Status, r.wroteHeader = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *StatusRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(p)
+	r.Bytes += int64(n)
+	return n, err
+}
+
+// Unwrap позволяет http.ResponseController добраться до исходного ответа.
+func (r *StatusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
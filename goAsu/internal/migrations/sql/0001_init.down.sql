@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS well_day_plans;
+DROP TABLE IF EXISTS well_day_histories;
+DROP TABLE IF EXISTS wells;
+DROP TABLE IF EXISTS objects;
+DROP TABLE IF EXISTS object_types;
@@ -0,0 +1,53 @@
+-- Базовая схема: справочник типов объектов, объекты, скважины,
+-- история и планы скважин за день. IF NOT EXISTS позволяет применить
+-- миграцию к базе, где таблицы уже были созданы вручную.
+
+CREATE TABLE IF NOT EXISTS object_types (
+    id   SERIAL PRIMARY KEY,
+    name TEXT   NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS objects (
+    id   SERIAL  PRIMARY KEY,
+    name TEXT    NOT NULL,
+    type INTEGER NOT NULL REFERENCES object_types (id)
+);
+
+CREATE INDEX IF NOT EXISTS objects_type_idx ON objects (type);
+
+CREATE TABLE IF NOT EXISTS wells (
+    well INTEGER PRIMARY KEY,
+    ngdu INTEGER NOT NULL REFERENCES objects (id),
+    cdng INTEGER NOT NULL REFERENCES objects (id),
+    kust INTEGER NOT NULL REFERENCES objects (id),
+    mest INTEGER NOT NULL REFERENCES objects (id)
+);
+
+CREATE INDEX IF NOT EXISTS wells_ngdu_idx ON wells (ngdu);
+CREATE INDEX IF NOT EXISTS wells_cdng_idx ON wells (cdng);
+CREATE INDEX IF NOT EXISTS wells_kust_idx ON wells (kust);
+CREATE INDEX IF NOT EXISTS wells_mest_idx ON wells (mest);
+
+CREATE TABLE IF NOT EXISTS well_day_histories (
+    well           INTEGER          NOT NULL REFERENCES wells (well),
+    date_fact      DATE             NOT NULL,
+    debit          DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ee_consume     DOUBLE PRECISION NOT NULL DEFAULT 0,
+    expenses       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    pump_operating DOUBLE PRECISION NOT NULL DEFAULT 0,
+    PRIMARY KEY (well, date_fact)
+);
+
+CREATE INDEX IF NOT EXISTS well_day_histories_date_fact_idx ON well_day_histories (date_fact);
+
+CREATE TABLE IF NOT EXISTS well_day_plans (
+    well           INTEGER          NOT NULL REFERENCES wells (well),
+    date_plan      DATE             NOT NULL,
+    debit          DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ee_consume     DOUBLE PRECISION NOT NULL DEFAULT 0,
+    expenses       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    pump_operating DOUBLE PRECISION NOT NULL DEFAULT 0,
+    PRIMARY KEY (well, date_plan)
+);
+
+CREATE INDEX IF NOT EXISTS well_day_plans_date_plan_idx ON well_day_plans (date_plan);
@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS well_day_plans_audit ON well_day_plans;
+DROP TRIGGER IF EXISTS well_day_histories_audit ON well_day_histories;
+DROP TRIGGER IF EXISTS wells_audit ON wells;
+DROP TRIGGER IF EXISTS objects_audit ON objects;
+DROP FUNCTION IF EXISTS audit_changes();
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,75 @@
+-- Журнал изменений объектов, скважин, истории и планов.
+-- Записи добавляет триггер audit_changes; автор и идентификатор запроса
+-- передаются приложением через параметры goasu.user и goasu.request_id,
+-- заданные на время транзакции. Изменения, выполненные в обход приложения,
+-- записываются от имени пользователя базы данных.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id          BIGSERIAL   PRIMARY KEY,
+    changed_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    entity      TEXT        NOT NULL,
+    entity_id   TEXT        NOT NULL,
+    well        INTEGER,
+    date        DATE,
+    action      TEXT        NOT NULL,
+    user_name   TEXT        NOT NULL,
+    request_id  TEXT        NOT NULL DEFAULT '',
+    old_value   JSONB,
+    new_value   JSONB
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (entity, entity_id);
+CREATE INDEX IF NOT EXISTS audit_log_well_date_idx ON audit_log (well, date);
+CREATE INDEX IF NOT EXISTS audit_log_request_id_idx ON audit_log (request_id);
+
+CREATE OR REPLACE FUNCTION audit_changes() RETURNS trigger AS $$
+DECLARE
+    old_row JSONB;
+    new_row JSONB;
+    rec     JSONB;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        old_row := to_jsonb(OLD);
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        new_row := to_jsonb(NEW);
+    END IF;
+    IF old_row = new_row THEN
+        RETURN NULL;
+    END IF;
+    rec := COALESCE(new_row, old_row);
+
+    INSERT INTO audit_log (entity, entity_id, well, date, action, user_name, request_id, old_value, new_value)
+    VALUES (
+        TG_TABLE_NAME,
+        CASE TG_TABLE_NAME
+            WHEN 'objects' THEN rec->>'id'
+            WHEN 'wells' THEN rec->>'well'
+            ELSE (rec->>'well') || '/' || COALESCE(rec->>'date_fact', rec->>'date_plan')
+        END,
+        (rec->>'well')::INTEGER,
+        COALESCE(rec->>'date_fact', rec->>'date_plan')::DATE,
+        CASE TG_OP WHEN 'INSERT' THEN 'create' WHEN 'UPDATE' THEN 'update' ELSE 'delete' END,
+        COALESCE(NULLIF(current_setting('goasu.user', true), ''), session_user),
+        COALESCE(current_setting('goasu.request_id', true), ''),
+        old_row,
+        new_row
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS objects_audit ON objects;
+CREATE TRIGGER objects_audit AFTER INSERT OR UPDATE OR DELETE ON objects
+    FOR EACH ROW EXECUTE FUNCTION audit_changes();
+
+DROP TRIGGER IF EXISTS wells_audit ON wells;
+CREATE TRIGGER wells_audit AFTER INSERT OR UPDATE OR DELETE ON wells
+    FOR EACH ROW EXECUTE FUNCTION audit_changes();
+
+DROP TRIGGER IF EXISTS well_day_histories_audit ON well_day_histories;
+CREATE TRIGGER well_day_histories_audit AFTER INSERT OR UPDATE OR DELETE ON well_day_histories
+    FOR EACH ROW EXECUTE FUNCTION audit_changes();
+
+DROP TRIGGER IF EXISTS well_day_plans_audit ON well_day_plans;
+CREATE TRIGGER well_day_plans_audit AFTER INSERT OR UPDATE OR DELETE ON well_day_plans
+    FOR EACH ROW EXECUTE FUNCTION audit_changes();
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS well_day_histories_versioning ON well_day_histories;
+DROP FUNCTION IF EXISTS well_day_history_versioning();
+DROP TABLE IF EXISTS well_day_history_versions;
@@ -0,0 +1,55 @@
+-- Версии записей истории скважин за день. Время действия данных — сутки
+-- date_fact; транзакционное время версии — [recorded_from, recorded_to),
+-- у текущей версии recorded_to пуст. Таблица well_day_histories по-прежнему
+-- хранит текущее состояние, а версии ведет триггер.
+CREATE TABLE IF NOT EXISTS well_day_history_versions (
+    well           INTEGER          NOT NULL,
+    date_fact      DATE             NOT NULL,
+    debit          DOUBLE PRECISION NOT NULL,
+    ee_consume     DOUBLE PRECISION NOT NULL,
+    expenses       DOUBLE PRECISION NOT NULL,
+    pump_operating DOUBLE PRECISION NOT NULL,
+    recorded_from  TIMESTAMPTZ      NOT NULL,
+    recorded_to    TIMESTAMPTZ,
+    PRIMARY KEY (well, date_fact, recorded_from),
+    CHECK (recorded_to IS NULL OR recorded_to > recorded_from)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS well_day_history_versions_current_idx
+    ON well_day_history_versions (well, date_fact) WHERE recorded_to IS NULL;
+CREATE INDEX IF NOT EXISTS well_day_history_versions_date_fact_idx
+    ON well_day_history_versions (date_fact);
+
+-- Существующие записи становятся первыми версиями. Прежние значения
+-- до появления версий неизвестны.
+INSERT INTO well_day_history_versions (well, date_fact, debit, ee_consume, expenses, pump_operating, recorded_from)
+SELECT well, date_fact, debit, ee_consume, expenses, pump_operating, now()
+FROM well_day_histories
+ON CONFLICT DO NOTHING;
+
+CREATE OR REPLACE FUNCTION well_day_history_versioning() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND OLD IS NOT DISTINCT FROM NEW THEN
+        RETURN NULL;
+    END IF;
+    IF TG_OP <> 'INSERT' THEN
+        -- Версия, созданная в этой же транзакции, не была видна никому
+        -- за ее пределами, поэтому она заменяется, а не закрывается.
+        DELETE FROM well_day_history_versions
+        WHERE well = OLD.well AND date_fact = OLD.date_fact AND recorded_from = now();
+        UPDATE well_day_history_versions SET recorded_to = now()
+        WHERE well = OLD.well AND date_fact = OLD.date_fact AND recorded_to IS NULL;
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        DELETE FROM well_day_history_versions
+        WHERE well = NEW.well AND date_fact = NEW.date_fact AND recorded_from = now();
+        INSERT INTO well_day_history_versions (well, date_fact, debit, ee_consume, expenses, pump_operating, recorded_from)
+        VALUES (NEW.well, NEW.date_fact, NEW.debit, NEW.ee_consume, NEW.expenses, NEW.pump_operating, now());
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS well_day_histories_versioning ON well_day_histories;
+CREATE TRIGGER well_day_histories_versioning AFTER INSERT OR UPDATE OR DELETE ON well_day_histories
+    FOR EACH ROW EXECUTE FUNCTION well_day_history_versioning();
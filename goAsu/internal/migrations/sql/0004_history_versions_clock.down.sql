@@ -0,0 +1,26 @@
+-- Возвращает отметку версий моментом начала транзакции (миграция 0003).
+CREATE OR REPLACE FUNCTION well_day_history_versioning() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND OLD IS NOT DISTINCT FROM NEW THEN
+        RETURN NULL;
+    END IF;
+    IF TG_OP <> 'INSERT' THEN
+        -- Версия, созданная в этой же транзакции, не была видна никому
+        -- за ее пределами, поэтому она заменяется, а не закрывается.
+        DELETE FROM well_day_history_versions
+        WHERE well = OLD.well AND date_fact = OLD.date_fact AND recorded_from = now();
+        UPDATE well_day_history_versions SET recorded_to = now()
+        WHERE well = OLD.well AND date_fact = OLD.date_fact AND recorded_to IS NULL;
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        DELETE FROM well_day_history_versions
+        WHERE well = NEW.well AND date_fact = NEW.date_fact AND recorded_from = now();
+        INSERT INTO well_day_history_versions (well, date_fact, debit, ee_consume, expenses, pump_operating, recorded_from)
+        VALUES (NEW.well, NEW.date_fact, NEW.debit, NEW.ee_consume, NEW.expenses, NEW.pump_operating, now());
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP FUNCTION IF EXISTS well_day_history_version_time(INTEGER, DATE);
+ALTER TABLE well_day_history_versions DROP COLUMN IF EXISTS recorded_tx;
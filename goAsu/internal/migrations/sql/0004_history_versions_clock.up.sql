@@ -0,0 +1,45 @@
+-- Версии записей истории отмечаются моментом изменения (clock_timestamp),
+-- а не началом транзакции (now()). Иначе транзакция, начатая раньше, но
+-- изменившая запись после другой, закрывала бы ее версию моментом,
+-- предшествующим началу версии, что нарушает CHECK (recorded_to > recorded_from).
+-- Версии, созданные текущей транзакцией, узнаются по recorded_tx.
+ALTER TABLE well_day_history_versions ADD COLUMN IF NOT EXISTS recorded_tx BIGINT;
+
+-- Момент новой версии записи (well, date_fact): текущее время, но не раньше
+-- начала последней версии, даже если часы сервера сдвинулись назад.
+CREATE OR REPLACE FUNCTION well_day_history_version_time(p_well INTEGER, p_date_fact DATE) RETURNS timestamptz AS $$
+    SELECT GREATEST(clock_timestamp(), max(recorded_from) + interval '1 microsecond')
+    FROM well_day_history_versions
+    WHERE well = p_well AND date_fact = p_date_fact;
+$$ LANGUAGE sql VOLATILE;
+
+CREATE OR REPLACE FUNCTION well_day_history_versioning() RETURNS trigger AS $$
+DECLARE
+    ts timestamptz;
+BEGIN
+    IF TG_OP = 'UPDATE' AND OLD IS NOT DISTINCT FROM NEW THEN
+        RETURN NULL;
+    END IF;
+    IF TG_OP <> 'INSERT' THEN
+        -- Версия, созданная в этой же транзакции, не была видна никому
+        -- за ее пределами, поэтому она удаляется, а не закрывается, и ее
+        -- момент переходит к следующей версии: прежняя версия уже закрыта им.
+        DELETE FROM well_day_history_versions
+        WHERE well = OLD.well AND date_fact = OLD.date_fact AND recorded_tx = txid_current()
+        RETURNING recorded_from INTO ts;
+        IF ts IS NULL THEN
+            ts := well_day_history_version_time(OLD.well, OLD.date_fact);
+            UPDATE well_day_history_versions SET recorded_to = ts
+            WHERE well = OLD.well AND date_fact = OLD.date_fact AND recorded_to IS NULL;
+        END IF;
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        IF TG_OP = 'INSERT' OR (OLD.well, OLD.date_fact) IS DISTINCT FROM (NEW.well, NEW.date_fact) THEN
+            ts := well_day_history_version_time(NEW.well, NEW.date_fact);
+        END IF;
+        INSERT INTO well_day_history_versions (well, date_fact, debit, ee_consume, expenses, pump_operating, recorded_from, recorded_tx)
+        VALUES (NEW.well, NEW.date_fact, NEW.debit, NEW.ee_consume, NEW.expenses, NEW.pump_operating, ts, txid_current());
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
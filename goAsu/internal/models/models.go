@@ -1,46 +1,222 @@
-package models
-
-type ObjectType struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
-}
-
-type Object struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
-	Type int    `json:"type"`
-}
-
-type Well struct {
-	Well int `json:"well"`
-	NGDU int `json:"ngdu"`
-	CDNG int `json:"cdng"`
-	Kust int `json:"kust"`
-	Mest int `json:"mest"`
-}
-
-type WellDayHistory struct {
-	Well          int     `json:"well"`
-	DateFact      string  `json:"date_fact"`
-	Debit         float64 `json:"debit"`
-	EEConsume     float64 `json:"ee_consume"`
-	Expenses      float64 `json:"expenses"`
-	PumpOperating float64 `json:"pump_operating"`
-}
-
-type WellDayPlan struct {
-	Well          int     `json:"well"`
-	DatePlan      string  `json:"date_plan"`
-	Debit         float64 `json:"debit"`
-	EEConsume     float64 `json:"ee_consume"`
-	Expenses      float64 `json:"expenses"`
-	PumpOperating float64 `json:"pump_operating"`
-}
-
-const (
-	BASE_IP  = "109.120.183.88"
-	PORT     = 5432
-	USERNAME = "hetsu"
-	PASSWORD = "Admin1234567890!"
-	BASENAME = "PostgreSQL-vitalick113"
-)
+package models
+
+import (
+	"encoding/json"
+	"goAsu/internal/civil"
+	"time"
+)
+
+type ObjectType struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type Object struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Type     int    `json:"type"`
+	TypeName string `json:"type_name,omitempty"`
+}
+
+type Well struct {
+	Well int `json:"well"`
+	NGDU int `json:"ngdu"`
+	CDNG int `json:"cdng"`
+	Kust int `json:"kust"`
+	Mest int `json:"mest"`
+}
+
+type WellDayHistory struct {
+	Well          int        `json:"well"`
+	DateFact      civil.Date `json:"date_fact" swaggertype:"string" format:"date" example:"2024-12-10"`
+	Debit         float64    `json:"debit"`
+	EEConsume     float64    `json:"ee_consume"`
+	Expenses      float64    `json:"expenses"`
+	PumpOperating float64    `json:"pump_operating"`
+}
+
+// WellDayHistoryVersion — версия записи истории за день. Данные относятся
+// к суткам DateFact (время действия); версия была текущей в системе
+// в промежутке [RecordedFrom, RecordedTo) (транзакционное время).
+// RecordedTo пуст у текущей версии.
+type WellDayHistoryVersion struct {
+	WellDayHistory
+	RecordedFrom time.Time  `json:"recorded_from"`
+	RecordedTo   *time.Time `json:"recorded_to,omitempty"`
+}
+
+type WellDayPlan struct {
+	Well          int        `json:"well"`
+	DatePlan      civil.Date `json:"date_plan" swaggertype:"string" format:"date" example:"2024-12-10"`
+	Debit         float64    `json:"debit"`
+	EEConsume     float64    `json:"ee_consume"`
+	Expenses      float64    `json:"expenses"`
+	PumpOperating float64    `json:"pump_operating"`
+}
+
+// MetricDeviation описывает плановое и фактическое значение показателя
+// и отклонение факта от плана. Отсутствующие значения передаются как null.
+type MetricDeviation struct {
+	Plan    *float64 `json:"plan"`
+	Fact    *float64 `json:"fact"`
+	Abs     *float64 `json:"abs"`
+	Percent *float64 `json:"percent"`
+}
+
+// PlanFactDeviation — строка отчета "план-факт" по скважине за один день.
+// Status принимает значения "both", "plan_only" и "fact_only".
+type PlanFactDeviation struct {
+	Well          int             `json:"well"`
+	Date          civil.Date      `json:"date" swaggertype:"string" format:"date" example:"2024-12-10"`
+	Status        string          `json:"status"`
+	Debit         MetricDeviation `json:"debit"`
+	EEConsume     MetricDeviation `json:"ee_consume"`
+	Expenses      MetricDeviation `json:"expenses"`
+	PumpOperating MetricDeviation `json:"pump_operating"`
+}
+
+// ProductionRollup — агрегированные показатели по объекту иерархии
+// (НГДУ, ЦДНГ, куст или месторождение) за период.
+// Period — первый день периода (дня, недели с понедельника или месяца).
+// Debit, EEConsume и Expenses суммируются, PumpOperating усредняется.
+type ProductionRollup struct {
+	Level         string     `json:"level"`
+	Object        int        `json:"object"`
+	ObjectName    string     `json:"object_name"`
+	Period        civil.Date `json:"period" swaggertype:"string" format:"date" example:"2024-12-01"`
+	Wells         int        `json:"wells"`
+	Debit         float64    `json:"debit"`
+	EEConsume     float64    `json:"ee_consume"`
+	Expenses      float64    `json:"expenses"`
+	PumpOperating float64    `json:"pump_operating"`
+}
+
+// WellDayTotals — суммарные показатели скважины за период [DateFrom, DateTo].
+// Days — число дней с фактическими данными; PumpOperating — суммарное
+// время работы насоса в часах.
+type WellDayTotals struct {
+	DateFrom      civil.Date `json:"date_from" swaggertype:"string" format:"date" example:"2024-11-11"`
+	DateTo        civil.Date `json:"date_to" swaggertype:"string" format:"date" example:"2024-12-10"`
+	Days          int        `json:"days"`
+	Debit         float64    `json:"debit"`
+	EEConsume     float64    `json:"ee_consume"`
+	Expenses      float64    `json:"expenses"`
+	PumpOperating float64    `json:"pump_operating"`
+}
+
+// WellCard — карточка скважины: скважина с объектами иерархии, последние
+// фактические данные, план на текущий день и итоги за 30 дней.
+// Объект иерархии, отсутствующий в справочнике, содержит только ID.
+// LatestFact и TodayPlan равны null, если данных нет.
+type WellCard struct {
+	Well       Well            `json:"well"`
+	NGDU       Object          `json:"ngdu"`
+	CDNG       Object          `json:"cdng"`
+	Kust       Object          `json:"kust"`
+	Mest       Object          `json:"mest"`
+	LatestFact *WellDayHistory `json:"latest_fact"`
+	TodayPlan  *WellDayPlan    `json:"today_plan"`
+	Last30Days WellDayTotals   `json:"last_30_days"`
+}
+
+// FieldError описывает недопустимое значение поля записи.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ErrorResponse — ответ об ошибке. Code — стабильный код ошибки
+// (например, not_found, conflict, validation_failed), Details — подробности,
+// зависящие от кода: список FieldError при validation_failed, имя нарушенного
+// ограничения при conflict и invalid_reference.
+type ErrorResponse struct {
+	Code      string      `json:"code" example:"not_found"`
+	Message   string      `json:"message" example:"Not found"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty" example:"4f3c2a1b9e8d7c6b5a4f3e2d1c0b9a8f"`
+}
+
+// ImportRowError описывает ошибку в строке импортируемого файла.
+// Row — номер строки файла (заголовок — строка 1), Column — имя столбца,
+// если ошибка относится к конкретному значению.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportResult — итог загрузки файла с дневными данными.
+type ImportResult struct {
+	Rows     int              `json:"rows"`
+	Imported int              `json:"imported"`
+	DryRun   bool             `json:"dry_run"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// Режимы записи пачки: atomic — пачка сохраняется целиком или не сохраняется
+// совсем; best_effort — сохраняются все элементы без ошибок.
+const (
+	BatchAtomic     = "atomic"
+	BatchBestEffort = "best_effort"
+)
+
+// BatchItemResult — результат записи элемента пачки. Index — номер элемента
+// в запросе, начиная с 0; Status — статус, который получил бы отдельный
+// запрос с этим элементом (200 — сохранен), или 424, если в режиме atomic
+// элемент не сохранен из-за ошибок других элементов. ID заполняется
+// для объектов.
+type BatchItemResult struct {
+	Index  int            `json:"index"`
+	Status int            `json:"status" example:"200"`
+	ID     int            `json:"id,omitempty"`
+	Error  *ErrorResponse `json:"error,omitempty"`
+}
+
+// BatchResult — итог записи пачки.
+type BatchResult struct {
+	Mode      string            `json:"mode" example:"atomic"`
+	Total     int               `json:"total"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Items     []BatchItemResult `json:"items"`
+}
+
+// AuditEntry — запись журнала изменений. EntityID — ключ записи: id объекта,
+// номер скважины или пара "скважина/дата" для истории и планов.
+// Old пуст при создании, New — при удалении.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	ChangedAt time.Time       `json:"changed_at"`
+	Entity    string          `json:"entity"`
+	EntityID  string          `json:"entity_id"`
+	Well      *int            `json:"well,omitempty"`
+	Date      *civil.Date     `json:"date,omitempty" swaggertype:"string" format:"date"`
+	Action    string          `json:"action"`
+	User      string          `json:"user"`
+	RequestID string          `json:"request_id"`
+	Old       json.RawMessage `json:"old,omitempty" swaggertype:"object"`
+	New       json.RawMessage `json:"new,omitempty" swaggertype:"object"`
+}
+
+const (
+	Ready    = "ready"
+	NotReady = "not_ready"
+
+	DatabaseOK          = "ok"
+	DatabaseUnavailable = "unavailable"
+)
+
+// Readiness — готовность сервиса принимать запросы. Database содержит "ok"
+// или "unavailable": ответ доступен без аутентификации, поэтому причина
+// ошибки пишется только в журнал сервера. MigrationVersion — последняя
+// примененная миграция схемы, ExpectedMigrationVersion — последняя
+// известная серверу; MigrationVersion не указывается, если версию схемы
+// не удалось прочитать. Сервис не готов, если база данных недоступна,
+// версия схемы неизвестна или схема устарела.
+type Readiness struct {
+	Status                   string `json:"status" example:"ready"`
+	Storage                  string `json:"storage" example:"postgres"`
+	Database                 string `json:"database,omitempty" example:"ok" enums:"ok,unavailable"`
+	MigrationVersion         *int   `json:"migration_version,omitempty" example:"3"`
+	ExpectedMigrationVersion *int   `json:"expected_migration_version,omitempty" example:"3"`
+}
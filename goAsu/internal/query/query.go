@@ -0,0 +1,178 @@
+// Package query разбирает параметры фильтрации, сортировки и постраничного
+// вывода для списочных GET-запросов и строит по ним фрагменты SQL.
+package query
+
+import (
+	"fmt"
+	"goAsu/internal/civil"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 1000
+	MaxLimit     = 10000
+)
+
+// Kind — тип значения фильтра.
+type Kind int
+
+const (
+	Int Kind = iota
+	String
+	Date
+)
+
+// Op — оператор сравнения фильтра.
+type Op int
+
+const (
+	// Eq — совпадение с одним из переданных значений.
+	Eq Op = iota
+	// Gte — значение поля не меньше переданного.
+	Gte
+	// Lte — значение поля не больше переданного.
+	Lte
+	// Contains — поле содержит подстроку без учета регистра.
+	Contains
+)
+
+// FilterSpec описывает допустимый параметр фильтрации.
+type FilterSpec struct {
+	Param string
+	Field string
+	Op    Op
+	Kind  Kind
+}
+
+// Spec описывает, какие фильтры и сортировки допустимы для ресурса.
+// Key — поля первичного ключа; они добавляются в конец сортировки,
+// чтобы постраничный вывод был стабильным.
+type Spec struct {
+	Filters  []FilterSpec
+	Sortable []string
+	Key      []string
+}
+
+// Filter — разобранное условие фильтрации. Values содержат int64, string
+// или civil.Date в зависимости от Kind.
+type Filter struct {
+	Field  string
+	Op     Op
+	Kind   Kind
+	Values []interface{}
+}
+
+// Sort — поле сортировки.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// List — разобранные параметры списочного запроса.
+// Limit, равный 0, означает выборку без ограничения числа записей.
+type List struct {
+	Filters []Filter
+	Sort    []Sort
+	Limit   int
+	Offset  int
+}
+
+// Parse разбирает параметры limit, offset, sort и фильтры, допустимые для spec.
+// Параметр sort задается списком полей через запятую, префикс "-" означает
+// сортировку по убыванию, например sort=well,-date_fact.
+func Parse(spec Spec, values url.Values) (List, error) {
+	list := List{Limit: DefaultLimit}
+
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return list, fmt.Errorf("Invalid limit: must be between 1 and %d", MaxLimit)
+		}
+		list.Limit = limit
+	}
+	if raw := values.Get("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return list, fmt.Errorf("Invalid offset")
+		}
+		list.Offset = offset
+	}
+
+	for _, fs := range spec.Filters {
+		raw, ok := values[fs.Param]
+		if !ok {
+			continue
+		}
+		filter := Filter{Field: fs.Field, Op: fs.Op, Kind: fs.Kind}
+		for _, value := range raw {
+			parts := []string{value}
+			if fs.Op == Eq {
+				parts = strings.Split(value, ",")
+			}
+			for _, part := range parts {
+				part = strings.TrimSpace(part)
+				if part == "" {
+					continue
+				}
+				v, err := parseValue(fs.Kind, part)
+				if err != nil {
+					return list, fmt.Errorf("Invalid %s", fs.Param)
+				}
+				filter.Values = append(filter.Values, v)
+			}
+		}
+		if len(filter.Values) == 0 {
+			continue
+		}
+		if fs.Op != Eq {
+			// Для операторов сравнения используется последнее переданное значение.
+			filter.Values = filter.Values[len(filter.Values)-1:]
+		}
+		list.Filters = append(list.Filters, filter)
+	}
+
+	seen := map[string]bool{}
+	if raw := values.Get("sort"); raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			part = strings.TrimSpace(part)
+			sort := Sort{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+			if !contains(spec.Sortable, sort.Field) {
+				return list, fmt.Errorf("Invalid sort field: %s", sort.Field)
+			}
+			if seen[sort.Field] {
+				continue
+			}
+			seen[sort.Field] = true
+			list.Sort = append(list.Sort, sort)
+		}
+	}
+	for _, field := range spec.Key {
+		if !seen[field] {
+			list.Sort = append(list.Sort, Sort{Field: field})
+		}
+	}
+
+	return list, nil
+}
+
+func parseValue(kind Kind, raw string) (interface{}, error) {
+	switch kind {
+	case Int:
+		return strconv.ParseInt(raw, 10, 64)
+	case Date:
+		return civil.Parse(raw)
+	default:
+		return raw, nil
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
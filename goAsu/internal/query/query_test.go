@@ -0,0 +1,108 @@
+package query
+
+import (
+	"goAsu/internal/civil"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testSpec = Spec{
+	Filters: []FilterSpec{
+		{Param: "well", Field: "well", Op: Eq, Kind: Int},
+		{Param: "date_from", Field: "date_fact", Op: Gte, Kind: Date},
+		{Param: "date_to", Field: "date_fact", Op: Lte, Kind: Date},
+		{Param: "name", Field: "name", Op: Contains, Kind: String},
+	},
+	Sortable: []string{"well", "date_fact", "debit"},
+	Key:      []string{"well", "date_fact"},
+}
+
+var defaultSort = []Sort{{Field: "well"}, {Field: "date_fact"}}
+
+func TestParse(t *testing.T) {
+	dec1 := civil.Date{Year: 2024, Month: time.December, Day: 1}
+	dec7 := civil.Date{Year: 2024, Month: time.December, Day: 7}
+	tests := []struct {
+		name  string
+		query string
+		want  List
+	}{
+		{"defaults", "", List{Limit: DefaultLimit, Sort: defaultSort}},
+		{"limit and offset", "limit=10&offset=20", List{Limit: 10, Offset: 20, Sort: defaultSort}},
+		{"max limit", "limit=10000", List{Limit: MaxLimit, Sort: defaultSort}},
+		{
+			"repeated and comma-separated values", "well=1,2&well=3",
+			List{Limit: DefaultLimit, Sort: defaultSort, Filters: []Filter{{Field: "well", Op: Eq, Kind: Int, Values: []interface{}{int64(1), int64(2), int64(3)}}}},
+		},
+		{"empty filter value", "well=", List{Limit: DefaultLimit, Sort: defaultSort}},
+		{
+			"date range", "date_from=2024-12-01&date_to=2024-12-07",
+			List{Limit: DefaultLimit, Sort: defaultSort, Filters: []Filter{
+				{Field: "date_fact", Op: Gte, Kind: Date, Values: []interface{}{dec1}},
+				{Field: "date_fact", Op: Lte, Kind: Date, Values: []interface{}{dec7}},
+			}},
+		},
+		{
+			"last value of a comparison", "date_from=2024-11-01&date_from=2024-12-01",
+			List{Limit: DefaultLimit, Sort: defaultSort, Filters: []Filter{{Field: "date_fact", Op: Gte, Kind: Date, Values: []interface{}{dec1}}}},
+		},
+		{
+			"contains keeps commas", "name=a,b",
+			List{Limit: DefaultLimit, Sort: defaultSort, Filters: []Filter{{Field: "name", Op: Contains, Kind: String, Values: []interface{}{"a,b"}}}},
+		},
+		{
+			"sort with key tie-breaker", "sort=-debit,well",
+			List{Limit: DefaultLimit, Sort: []Sort{{Field: "debit", Desc: true}, {Field: "well"}, {Field: "date_fact"}}},
+		},
+		{
+			"duplicate sort field", "sort=-date_fact,date_fact",
+			List{Limit: DefaultLimit, Sort: []Sort{{Field: "date_fact", Desc: true}, {Field: "well"}}},
+		},
+		{"unknown parameters ignored", "foo=bar", List{Limit: DefaultLimit, Sort: defaultSort}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Parse(testSpec, values)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"limit=0", "Invalid limit: must be between 1 and 10000"},
+		{"limit=10001", "Invalid limit: must be between 1 and 10000"},
+		{"limit=ten", "Invalid limit: must be between 1 and 10000"},
+		{"offset=-1", "Invalid offset"},
+		{"well=1,x", "Invalid well"},
+		{"date_from=01.12.2024", "Invalid date_from"},
+		{"sort=name", "Invalid sort field: name"},
+		{"sort=well,", "Invalid sort field: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = Parse(testSpec, values)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Parse error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package query
+
+import (
+	"fmt"
+	"goAsu/internal/civil"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Where возвращает условие WHERE (с ведущим пробелом, либо пустую строку)
+// и его аргументы. prefix добавляется к именам столбцов, например "o.".
+func (l List) Where(prefix string) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	for _, f := range l.Filters {
+		column := prefix + f.Field
+		switch f.Op {
+		case Eq:
+			if len(f.Values) == 1 {
+				args = append(args, f.Values[0])
+				conditions = append(conditions, fmt.Sprintf("%s = $%d", column, len(args)))
+			} else {
+				args = append(args, arrayArg(f))
+				cast := ""
+				if f.Kind == Date {
+					cast = "::date[]"
+				}
+				conditions = append(conditions, fmt.Sprintf("%s = ANY($%d%s)", column, len(args), cast))
+			}
+		case Gte:
+			args = append(args, f.Values[0])
+			conditions = append(conditions, fmt.Sprintf("%s >= $%d", column, len(args)))
+		case Lte:
+			args = append(args, f.Values[0])
+			conditions = append(conditions, fmt.Sprintf("%s <= $%d", column, len(args)))
+		case Contains:
+			args = append(args, f.Values[0])
+			conditions = append(conditions, fmt.Sprintf("%s ILIKE '%%' || $%d || '%%'", column, len(args)))
+		}
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// OrderBy возвращает предложение ORDER BY (с ведущим пробелом).
+func (l List) OrderBy(prefix string) string {
+	if len(l.Sort) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(l.Sort))
+	for _, s := range l.Sort {
+		part := prefix + s.Field
+		if s.Desc {
+			part += " DESC"
+		}
+		parts = append(parts, part)
+	}
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// Page дополняет args значениями LIMIT и OFFSET и возвращает соответствующий фрагмент SQL.
+// При нулевом Limit задается только OFFSET.
+func (l List) Page(args []interface{}) (string, []interface{}) {
+	if l.Limit == 0 {
+		args = append(args, l.Offset)
+		return fmt.Sprintf(" OFFSET $%d", len(args)), args
+	}
+	args = append(args, l.Limit, l.Offset)
+	return fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args)), args
+}
+
+func arrayArg(f Filter) interface{} {
+	switch f.Kind {
+	case Int:
+		values := make([]int64, len(f.Values))
+		for i, v := range f.Values {
+			values[i] = v.(int64)
+		}
+		return pq.Array(values)
+	case Date:
+		values := make([]string, len(f.Values))
+		for i, v := range f.Values {
+			values[i] = v.(civil.Date).String()
+		}
+		return pq.Array(values)
+	default:
+		values := make([]string, len(f.Values))
+		for i, v := range f.Values {
+			values[i] = v.(string)
+		}
+		return pq.Array(values)
+	}
+}
@@ -0,0 +1,100 @@
+package query
+
+import (
+	"goAsu/internal/civil"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestWhere(t *testing.T) {
+	dec1 := civil.Date{Year: 2024, Month: time.December, Day: 1}
+	dec2 := civil.Date{Year: 2024, Month: time.December, Day: 2}
+	tests := []struct {
+		name    string
+		filters []Filter
+		want    string
+		args    []interface{}
+	}{
+		{"no filters", nil, "", nil},
+		{
+			"single value",
+			[]Filter{{Field: "well", Op: Eq, Kind: Int, Values: []interface{}{int64(1)}}},
+			" WHERE t.well = $1", []interface{}{int64(1)},
+		},
+		{
+			"int list",
+			[]Filter{{Field: "well", Op: Eq, Kind: Int, Values: []interface{}{int64(1), int64(2)}}},
+			" WHERE t.well = ANY($1)", []interface{}{pq.Array([]int64{1, 2})},
+		},
+		{
+			"date list",
+			[]Filter{{Field: "date_fact", Op: Eq, Kind: Date, Values: []interface{}{dec1, dec2}}},
+			" WHERE t.date_fact = ANY($1::date[])", []interface{}{pq.Array([]string{"2024-12-01", "2024-12-02"})},
+		},
+		{
+			"range and substring",
+			[]Filter{
+				{Field: "date_fact", Op: Gte, Kind: Date, Values: []interface{}{dec1}},
+				{Field: "date_fact", Op: Lte, Kind: Date, Values: []interface{}{dec2}},
+				{Field: "name", Op: Contains, Kind: String, Values: []interface{}{"куст"}},
+			},
+			" WHERE t.date_fact >= $1 AND t.date_fact <= $2 AND t.name ILIKE '%' || $3 || '%'",
+			[]interface{}{dec1, dec2, "куст"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args := List{Filters: tt.filters}.Where("t.")
+			if got != tt.want {
+				t.Errorf("Where = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	tests := []struct {
+		sort []Sort
+		want string
+	}{
+		{nil, ""},
+		{[]Sort{{Field: "well"}}, " ORDER BY o.well"},
+		{[]Sort{{Field: "debit", Desc: true}, {Field: "well"}}, " ORDER BY o.debit DESC, o.well"},
+	}
+	for _, tt := range tests {
+		if got := (List{Sort: tt.sort}).OrderBy("o."); got != tt.want {
+			t.Errorf("OrderBy(%v) = %q, want %q", tt.sort, got, tt.want)
+		}
+	}
+}
+
+func TestPage(t *testing.T) {
+	tests := []struct {
+		name  string
+		list  List
+		want  string
+		args  []interface{}
+		given []interface{}
+	}{
+		{"limit and offset", List{Limit: 10, Offset: 20}, " LIMIT $1 OFFSET $2", []interface{}{10, 20}, nil},
+		{"after filter args", List{Limit: 10}, " LIMIT $2 OFFSET $3", []interface{}{int64(1), 10, 0}, []interface{}{int64(1)}},
+		{"no limit", List{Offset: 5}, " OFFSET $1", []interface{}{5}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args := tt.list.Page(tt.given)
+			if got != tt.want {
+				t.Errorf("Page = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"goAsu/internal/audit"
+	"goAsu/internal/civil"
+	"goAsu/internal/models"
+	"goAsu/internal/query"
+	"time"
+)
+
+type auditRepository struct {
+	d *data
+}
+
+func auditField(entry models.AuditEntry, field string) interface{} {
+	switch field {
+	case "id":
+		return entry.ID
+	case "changed_at":
+		return entry.ChangedAt.UTC().Format(time.RFC3339Nano)
+	case "entity":
+		return entry.Entity
+	case "entity_id":
+		return entry.EntityID
+	case "well":
+		if entry.Well != nil {
+			return int64(*entry.Well)
+		}
+	case "date":
+		if entry.Date != nil {
+			return *entry.Date
+		}
+	case "action":
+		return entry.Action
+	case "user_name":
+		return entry.User
+	case "request_id":
+		return entry.RequestID
+	}
+	return nil
+}
+
+func (r *auditRepository) List(ctx context.Context, q query.List) ([]models.AuditEntry, int, error) {
+	r.d.mu.RLock()
+	defer r.d.mu.RUnlock()
+
+	page, total := list(r.d.audit, q, auditField)
+	return append([]models.AuditEntry{}, page...), total, nil
+}
+
+// record добавляет запись в журнал изменений. Вызывается под блокировкой
+// на запись вместе с самим изменением. oldValue равен nil при создании,
+// newValue — при удалении; изменение, не меняющее значений, не записывается.
+func (d *data) record(ctx context.Context, entity, entityID string, well *int, date *civil.Date, oldValue, newValue interface{}) {
+	oldJSON, newJSON := marshal(oldValue), marshal(newValue)
+	if oldJSON != nil && string(oldJSON) == string(newJSON) {
+		return
+	}
+	action := audit.Update
+	switch {
+	case oldValue == nil:
+		action = audit.Create
+	case newValue == nil:
+		action = audit.Delete
+	}
+	user, requestID := audit.Actor(ctx)
+	d.nextAuditID++
+	d.audit = append(d.audit, models.AuditEntry{
+		ID:        d.nextAuditID,
+		ChangedAt: time.Now(),
+		Entity:    entity,
+		EntityID:  entityID,
+		Well:      well,
+		Date:      date,
+		Action:    action,
+		User:      user,
+		RequestID: requestID,
+		Old:       oldJSON,
+		New:       newJSON,
+	})
+}
+
+func (d *data) recordObject(ctx context.Context, oldValue, newValue *models.Object) {
+	obj := pick(oldValue, newValue)
+	d.record(ctx, "objects", fmt.Sprint(obj.ID), nil, nil, nilIfEmpty(oldValue), nilIfEmpty(newValue))
+}
+
+func (d *data) recordWell(ctx context.Context, oldValue, newValue *models.Well) {
+	well := pick(oldValue, newValue).Well
+	d.record(ctx, "wells", fmt.Sprint(well), &well, nil, nilIfEmpty(oldValue), nilIfEmpty(newValue))
+}
+
+func (d *data) recordHistory(ctx context.Context, oldValue, newValue *models.WellDayHistory) {
+	history := pick(oldValue, newValue)
+	well, date := history.Well, history.DateFact
+	d.record(ctx, "well_day_histories", fmt.Sprintf("%d/%s", well, date), &well, &date, nilIfEmpty(oldValue), nilIfEmpty(newValue))
+}
+
+func (d *data) recordPlan(ctx context.Context, oldValue, newValue *models.WellDayPlan) {
+	plan := pick(oldValue, newValue)
+	well, date := plan.Well, plan.DatePlan
+	d.record(ctx, "well_day_plans", fmt.Sprintf("%d/%s", well, date), &well, &date, nilIfEmpty(oldValue), nilIfEmpty(newValue))
+}
+
+func pick[T any](oldValue, newValue *T) T {
+	if newValue != nil {
+		return *newValue
+	}
+	return *oldValue
+}
+
+// nilIfEmpty превращает пустой указатель в nil-интерфейс, чтобы record
+// могла отличить создание и удаление от изменения.
+func nilIfEmpty[T any](v *T) interface{} {
+	if v == nil {
+		return nil
+	}
+	return v
+}
+
+func marshal(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, _ := json.Marshal(v)
+	return data
+}
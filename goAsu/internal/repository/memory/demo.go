@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"goAsu/internal/civil"
+	"goAsu/internal/models"
+	"goAsu/internal/repository"
+	"time"
+)
+
+// NewDemo возвращает хранилище в памяти, заполненное небольшим набором
+// демонстрационных данных: иерархией объектов, двумя скважинами и неделей
+// плановых и фактических показателей.
+func NewDemo() *repository.Store {
+	d := newData()
+
+	for _, name := range []string{"НГДУ", "ЦДНГ", "Куст", "Месторождение"} {
+		d.objectTypes[d.nextObjectTypeID] = models.ObjectType{ID: d.nextObjectTypeID, Name: name}
+		d.nextObjectTypeID++
+	}
+	for _, obj := range []models.Object{
+		{Name: "НГДУ-1", Type: 1},
+		{Name: "ЦДНГ-1", Type: 2},
+		{Name: "ЦДНГ-2", Type: 2},
+		{Name: "Куст 101", Type: 3},
+		{Name: "Куст 102", Type: 3},
+		{Name: "Северное", Type: 4},
+	} {
+		obj.ID = d.nextObjectID
+		d.objects[obj.ID] = obj
+		d.nextObjectID++
+	}
+
+	d.wells[4455] = models.Well{Well: 4455, NGDU: 1, CDNG: 2, Kust: 4, Mest: 6}
+	d.wells[4456] = models.Well{Well: 4456, NGDU: 1, CDNG: 3, Kust: 5, Mest: 6}
+
+	start := civil.Date{Year: 2024, Month: time.December, Day: 1}
+	for i := 0; i < 7; i++ {
+		date := start.AddDays(i)
+		for n, well := range []int{4455, 4456} {
+			base := float64(20 + 5*n)
+			d.plans[dayKey{well, date}] = models.WellDayPlan{
+				Well: well, DatePlan: date,
+				Debit: base, EEConsume: base * 3, Expenses: base / 4, PumpOperating: 24,
+			}
+			// Последний день недели еще не внесен в историю.
+			if i == 6 {
+				continue
+			}
+			factor := 0.9 + 0.05*float64(i%4)
+			d.histories[dayKey{well, date}] = models.WellDayHistory{
+				Well: well, DateFact: date,
+				Debit: base * factor, EEConsume: base * 3 * factor, Expenses: base / 4 * factor, PumpOperating: 24 - float64(i%3),
+			}
+		}
+	}
+
+	for key, history := range d.histories {
+		d.version(key, &history)
+	}
+
+	return newStore(d)
+}
@@ -0,0 +1,170 @@
+// Package memory реализует репозитории, хранящие данные в памяти процесса.
+// Реализация предназначена для тестов и демонстрационного режима.
+package memory
+
+import (
+	"goAsu/internal/civil"
+	"goAsu/internal/models"
+	"goAsu/internal/query"
+	"goAsu/internal/repository"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type dayKey struct {
+	well int
+	date civil.Date
+}
+
+// data хранит все ресурсы под общей блокировкой, чтобы проверки ссылок
+// и отчеты видели согласованное состояние.
+type data struct {
+	mu               sync.RWMutex
+	objectTypes      map[int]models.ObjectType
+	objects          map[int]models.Object
+	wells            map[int]models.Well
+	histories        map[dayKey]models.WellDayHistory
+	plans            map[dayKey]models.WellDayPlan
+	versions         map[dayKey][]models.WellDayHistoryVersion
+	audit            []models.AuditEntry
+	nextObjectTypeID int
+	nextObjectID     int
+	nextAuditID      int64
+}
+
+// New возвращает пустое хранилище в памяти.
+func New() *repository.Store {
+	return newStore(newData())
+}
+
+func newData() *data {
+	return &data{
+		objectTypes:      map[int]models.ObjectType{},
+		objects:          map[int]models.Object{},
+		wells:            map[int]models.Well{},
+		histories:        map[dayKey]models.WellDayHistory{},
+		plans:            map[dayKey]models.WellDayPlan{},
+		versions:         map[dayKey][]models.WellDayHistoryVersion{},
+		nextObjectTypeID: 1,
+		nextObjectID:     1,
+	}
+}
+
+func newStore(d *data) *repository.Store {
+	return &repository.Store{
+		ObjectTypes: &objectTypeRepository{d: d},
+		Objects:     &objectRepository{d: d},
+		Wells:       &wellRepository{d: d},
+		Histories:   &wellDayHistoryRepository{d: d},
+		Plans:       &wellDayPlanRepository{d: d},
+		Reports:     &reportRepository{d: d},
+		Audit:       &auditRepository{d: d},
+	}
+}
+
+// list применяет к items фильтры, сортировку и постраничный вывод из q
+// и возвращает страницу и общее число подходящих записей.
+// field возвращает значение поля записи по имени: int64, float64, string
+// или civil.Date.
+func list[T any](items []T, q query.List, field func(T, string) interface{}) ([]T, int) {
+	var matched []T
+	for _, item := range items {
+		if matches(item, q.Filters, field) {
+			matched = append(matched, item)
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		for _, s := range q.Sort {
+			c := compare(field(matched[i], s.Field), field(matched[j], s.Field))
+			if c != 0 {
+				return (c < 0) != s.Desc
+			}
+		}
+		return false
+	})
+
+	total := len(matched)
+	if q.Offset >= total {
+		return nil, total
+	}
+	end := total
+	if q.Limit > 0 && q.Offset+q.Limit < end {
+		end = q.Offset + q.Limit
+	}
+	return matched[q.Offset:end], total
+}
+
+// each вызывает fn для каждой записи items. Вызывается после снятия
+// блокировки, чтобы медленный получатель не задерживал запись данных.
+func each[T any](items []T, fn func(T) error) error {
+	for _, item := range items {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func matches[T any](item T, filters []query.Filter, field func(T, string) interface{}) bool {
+	for _, f := range filters {
+		value := field(item, f.Field)
+		switch f.Op {
+		case query.Eq:
+			found := false
+			for _, v := range f.Values {
+				if compare(value, v) == 0 {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		case query.Gte:
+			if compare(value, f.Values[0]) < 0 {
+				return false
+			}
+		case query.Lte:
+			if compare(value, f.Values[0]) > 0 {
+				return false
+			}
+		case query.Contains:
+			s, _ := value.(string)
+			sub, _ := f.Values[0].(string)
+			if !strings.Contains(strings.ToLower(s), strings.ToLower(sub)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func compare(a, b interface{}) int {
+	switch a := a.(type) {
+	case int64:
+		b, _ := b.(int64)
+		return cmpOrdered(a, b)
+	case float64:
+		b, _ := b.(float64)
+		return cmpOrdered(a, b)
+	case string:
+		b, _ := b.(string)
+		return cmpOrdered(a, b)
+	case civil.Date:
+		b, _ := b.(civil.Date)
+		return a.Compare(b)
+	}
+	return 0
+}
+
+func cmpOrdered[T int64 | float64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"goAsu/internal/models"
+	"goAsu/internal/repository"
+	"sort"
+)
+
+type objectTypeRepository struct {
+	d *data
+}
+
+func (r *objectTypeRepository) List(ctx context.Context) ([]models.ObjectType, error) {
+	r.d.mu.RLock()
+	defer r.d.mu.RUnlock()
+
+	var objectTypes []models.ObjectType
+	for _, objType := range r.d.objectTypes {
+		objectTypes = append(objectTypes, objType)
+	}
+	sort.Slice(objectTypes, func(i, j int) bool { return objectTypes[i].ID < objectTypes[j].ID })
+	return objectTypes, nil
+}
+
+func (r *objectTypeRepository) Get(ctx context.Context, id int) (models.ObjectType, error) {
+	r.d.mu.RLock()
+	defer r.d.mu.RUnlock()
+
+	objType, ok := r.d.objectTypes[id]
+	if !ok {
+		return objType, repository.ErrNotFound
+	}
+	return objType, nil
+}
+
+func (r *objectTypeRepository) Create(ctx context.Context, objType *models.ObjectType) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	objType.ID = r.d.nextObjectTypeID
+	r.d.nextObjectTypeID++
+	r.d.objectTypes[objType.ID] = *objType
+	return nil
+}
+
+func (r *objectTypeRepository) Update(ctx context.Context, objType models.ObjectType) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	if _, ok := r.d.objectTypes[objType.ID]; !ok {
+		return repository.ErrNotFound
+	}
+	r.d.objectTypes[objType.ID] = objType
+	return nil
+}
+
+func (r *objectTypeRepository) Delete(ctx context.Context, id int) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	for _, obj := range r.d.objects {
+		if obj.Type == id {
+			return repository.ErrInUse
+		}
+	}
+	if _, ok := r.d.objectTypes[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.d.objectTypes, id)
+	return nil
+}
@@ -0,0 +1,165 @@
+package memory
+
+import (
+	"context"
+	"goAsu/internal/models"
+	"goAsu/internal/query"
+	"goAsu/internal/repository"
+)
+
+type objectRepository struct {
+	d *data
+}
+
+func objectField(obj models.Object, field string) interface{} {
+	switch field {
+	case "id":
+		return int64(obj.ID)
+	case "name":
+		return obj.Name
+	case "type":
+		return int64(obj.Type)
+	}
+	return nil
+}
+
+func (r *objectRepository) List(ctx context.Context, q query.List) ([]models.Object, int, error) {
+	r.d.mu.RLock()
+	defer r.d.mu.RUnlock()
+
+	objects := make([]models.Object, 0, len(r.d.objects))
+	for _, obj := range r.d.objects {
+		obj.TypeName = r.d.objectTypes[obj.Type].Name
+		objects = append(objects, obj)
+	}
+	page, total := list(objects, q, objectField)
+	return page, total, nil
+}
+
+func (r *objectRepository) Each(ctx context.Context, q query.List, fn func(models.Object) error) error {
+	page, _, err := r.List(ctx, q)
+	if err != nil {
+		return err
+	}
+	return each(page, fn)
+}
+
+func (r *objectRepository) Get(ctx context.Context, id int) (models.Object, error) {
+	r.d.mu.RLock()
+	defer r.d.mu.RUnlock()
+
+	obj, ok := r.d.objects[id]
+	if !ok {
+		return obj, repository.ErrNotFound
+	}
+	obj.TypeName = r.d.objectTypes[obj.Type].Name
+	return obj, nil
+}
+
+func (r *objectRepository) Create(ctx context.Context, obj *models.Object) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	if err := r.d.checkObject(*obj); err != nil {
+		return err
+	}
+	obj.ID = r.d.nextObjectID
+	r.d.nextObjectID++
+	stored := *obj
+	stored.TypeName = ""
+	r.d.objects[obj.ID] = stored
+	r.d.recordObject(ctx, nil, &stored)
+	return nil
+}
+
+func (r *objectRepository) Update(ctx context.Context, obj models.Object) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	old, ok := r.d.objects[obj.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := r.d.checkObject(obj); err != nil {
+		return err
+	}
+	obj.TypeName = ""
+	r.d.objects[obj.ID] = obj
+	r.d.recordObject(ctx, &old, &obj)
+	return nil
+}
+
+func (r *objectRepository) Patch(ctx context.Context, id int, apply func(obj *models.Object) error) (models.Object, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	old, ok := r.d.objects[id]
+	if !ok {
+		return old, repository.ErrNotFound
+	}
+	obj := old
+	if err := apply(&obj); err != nil {
+		return obj, err
+	}
+	if err := r.d.checkObject(obj); err != nil {
+		return obj, err
+	}
+	obj.ID, obj.TypeName = id, ""
+	r.d.objects[id] = obj
+	r.d.recordObject(ctx, &old, &obj)
+	obj.TypeName = r.d.objectTypes[obj.Type].Name
+	return obj, nil
+}
+
+func (r *objectRepository) Delete(ctx context.Context, id int) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	old, ok := r.d.objects[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if r.d.objectInUse(id) {
+		return repository.ErrInUse
+	}
+	delete(r.d.objects, id)
+	r.d.recordObject(ctx, &old, nil)
+	return nil
+}
+
+func (r *objectRepository) SaveBatch(ctx context.Context, objects []models.Object, atomic bool) ([]error, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	errs := make([]error, len(objects))
+	failed := false
+	for i, obj := range objects {
+		if _, ok := r.d.objects[obj.ID]; obj.ID != 0 && !ok {
+			errs[i], failed = repository.ErrNotFound, true
+		} else if err := r.d.checkObject(obj); err != nil {
+			errs[i], failed = err, true
+		}
+	}
+	if failed && atomic {
+		return errs, nil
+	}
+
+	for i := range objects {
+		if errs[i] != nil {
+			continue
+		}
+		obj := &objects[i]
+		obj.TypeName = ""
+		var old *models.Object
+		if obj.ID == 0 {
+			obj.ID = r.d.nextObjectID
+			r.d.nextObjectID++
+		} else {
+			stored := r.d.objects[obj.ID]
+			old = &stored
+		}
+		r.d.objects[obj.ID] = *obj
+		r.d.recordObject(ctx, old, obj)
+	}
+	return errs, nil
+}
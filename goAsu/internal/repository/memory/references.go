@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"goAsu/internal/models"
+	"goAsu/internal/repository"
+)
+
+// Проверки ссылок повторяют внешние ключи схемы PostgreSQL и возвращают
+// те же ошибки с теми же именами ограничений, чтобы обработчики вели себя
+// одинаково с обоими хранилищами. Вызываются под блокировкой.
+
+func invalidReference(constraint string) error {
+	return &repository.ConstraintError{Err: repository.ErrInvalidReference, Constraint: constraint}
+}
+
+// checkObject проверяет, что тип объекта есть в справочнике.
+func (d *data) checkObject(obj models.Object) error {
+	if _, ok := d.objectTypes[obj.Type]; !ok {
+		return invalidReference("objects_type_fkey")
+	}
+	return nil
+}
+
+// checkWell проверяет, что объекты иерархии скважины существуют.
+func (d *data) checkWell(well models.Well) error {
+	refs := []struct {
+		id         int
+		constraint string
+	}{
+		{well.NGDU, "wells_ngdu_fkey"},
+		{well.CDNG, "wells_cdng_fkey"},
+		{well.Kust, "wells_kust_fkey"},
+		{well.Mest, "wells_mest_fkey"},
+	}
+	for _, ref := range refs {
+		if _, ok := d.objects[ref.id]; !ok {
+			return invalidReference(ref.constraint)
+		}
+	}
+	return nil
+}
+
+// checkDayWell проверяет, что скважина дневной записи таблицы table
+// существует.
+func (d *data) checkDayWell(table string, well int) error {
+	if _, ok := d.wells[well]; !ok {
+		return invalidReference(table + "_well_fkey")
+	}
+	return nil
+}
+
+// objectInUse сообщает, ссылается ли на объект id какая-либо скважина.
+func (d *data) objectInUse(id int) bool {
+	for _, well := range d.wells {
+		if well.NGDU == id || well.CDNG == id || well.Kust == id || well.Mest == id {
+			return true
+		}
+	}
+	return false
+}
+
+// wellInUse сообщает, есть ли у скважины история или планы.
+func (d *data) wellInUse(well int) bool {
+	for key := range d.histories {
+		if key.well == well {
+			return true
+		}
+	}
+	for key := range d.plans {
+		if key.well == well {
+			return true
+		}
+	}
+	return false
+}
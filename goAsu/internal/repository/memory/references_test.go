@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"goAsu/internal/civil"
+	"goAsu/internal/models"
+	"goAsu/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestDayRecordReferences(t *testing.T) {
+	ctx := context.Background()
+	store := NewDemo()
+	date := civil.Date{Year: 2024, Month: time.December, Day: 8}
+	valid := models.WellDayHistory{Well: 4455, DateFact: date, Debit: 1}
+	missing := models.WellDayHistory{Well: 1, DateFact: date, Debit: 1}
+
+	var constraint *repository.ConstraintError
+	err := store.Histories.Create(ctx, missing)
+	if !errors.As(err, &constraint) || !errors.Is(err, repository.ErrInvalidReference) || constraint.Constraint != "well_day_histories_well_fkey" {
+		t.Fatalf("Create = %v, want invalid reference to well_day_histories_well_fkey", err)
+	}
+	if _, err := store.Plans.Put(ctx, models.WellDayPlan{Well: 1, DatePlan: date}, nil); !errors.Is(err, repository.ErrInvalidReference) {
+		t.Errorf("Put plan = %v, want %v", err, repository.ErrInvalidReference)
+	}
+	if err := store.Histories.Upsert(ctx, []models.WellDayHistory{valid, missing}); !errors.Is(err, repository.ErrInvalidReference) {
+		t.Errorf("Upsert = %v, want %v", err, repository.ErrInvalidReference)
+	}
+	if _, err := store.Histories.Get(ctx, 4455, date); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Upsert saved part of the records: Get = %v", err)
+	}
+
+	errs, err := store.Histories.UpsertBatch(ctx, []models.WellDayHistory{valid, missing}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs[0] != nil || !errors.Is(errs[1], repository.ErrInvalidReference) {
+		t.Errorf("UpsertBatch errors = %v, want [nil, invalid reference]", errs)
+	}
+	if _, err := store.Histories.Get(ctx, 4455, date); err != nil {
+		t.Errorf("valid record not saved: %v", err)
+	}
+}
+
+func TestDeleteInUse(t *testing.T) {
+	ctx := context.Background()
+	store := NewDemo()
+	if err := store.Wells.Delete(ctx, 4455); !errors.Is(err, repository.ErrInUse) {
+		t.Errorf("delete well with history = %v, want %v", err, repository.ErrInUse)
+	}
+	if err := store.Objects.Delete(ctx, 6); !errors.Is(err, repository.ErrInUse) {
+		t.Errorf("delete object used by wells = %v, want %v", err, repository.ErrInUse)
+	}
+	if err := store.ObjectTypes.Delete(ctx, 1); !errors.Is(err, repository.ErrInUse) {
+		t.Errorf("delete object type used by objects = %v, want %v", err, repository.ErrInUse)
+	}
+}
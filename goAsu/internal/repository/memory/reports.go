@@ -0,0 +1,243 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"goAsu/internal/civil"
+	"goAsu/internal/models"
+	"goAsu/internal/repository"
+	"sort"
+)
+
+type reportRepository struct {
+	d *data
+}
+
+func (r *reportRepository) PlanFact(ctx context.Context, filter repository.PlanFactFilter) ([]models.PlanFactDeviation, error) {
+	r.d.mu.RLock()
+	defer r.d.mu.RUnlock()
+
+	histories := r.d.historiesAsOf(filter.AsOf)
+	inRange := func(well int, date civil.Date) bool {
+		return !date.Before(filter.DateFrom) && !date.After(filter.DateTo) && containsID(filter.Wells, well)
+	}
+
+	keys := map[dayKey]bool{}
+	for key := range r.d.plans {
+		if inRange(key.well, key.date) {
+			keys[key] = true
+		}
+	}
+	for key := range histories {
+		if inRange(key.well, key.date) {
+			keys[key] = true
+		}
+	}
+
+	report := []models.PlanFactDeviation{}
+	for key := range keys {
+		var plan *models.WellDayPlan
+		if p, ok := r.d.plans[key]; ok {
+			plan = &p
+		}
+		var fact *models.WellDayHistory
+		if h, ok := histories[key]; ok {
+			fact = &h
+		}
+		report = append(report, repository.NewPlanFactDeviation(key.well, key.date, plan, fact))
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Well != report[j].Well {
+			return report[i].Well < report[j].Well
+		}
+		return report[i].Date.Before(report[j].Date)
+	})
+	return report, nil
+}
+
+type rollupKey struct {
+	object int
+	period civil.Date
+}
+
+type rollupAccumulator struct {
+	row   models.ProductionRollup
+	wells map[int]bool
+	days  int
+}
+
+func (r *reportRepository) Rollup(ctx context.Context, filter repository.RollupFilter) ([]models.ProductionRollup, error) {
+	r.d.mu.RLock()
+	defer r.d.mu.RUnlock()
+
+	if filter.AsOf != nil && filter.Source != "fact" {
+		return nil, fmt.Errorf("as-of queries are supported only for fact data")
+	}
+	var days []models.WellDayHistory
+	switch filter.Source {
+	case "fact":
+		for _, history := range r.d.historiesAsOf(filter.AsOf) {
+			days = append(days, history)
+		}
+	case "plan":
+		// План и факт имеют одинаковый набор показателей, поэтому для
+		// агрегирования плановые дни приводятся к WellDayHistory.
+		for _, plan := range r.d.plans {
+			days = append(days, models.WellDayHistory{
+				Well:          plan.Well,
+				DateFact:      plan.DatePlan,
+				Debit:         plan.Debit,
+				EEConsume:     plan.EEConsume,
+				Expenses:      plan.Expenses,
+				PumpOperating: plan.PumpOperating,
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unknown rollup source %q", filter.Source)
+	}
+
+	groups := map[rollupKey]*rollupAccumulator{}
+	for _, day := range days {
+		if day.DateFact.Before(filter.DateFrom) || day.DateFact.After(filter.DateTo) {
+			continue
+		}
+		well, ok := r.d.wells[day.Well]
+		if !ok || !r.inHierarchy(well, filter.Hierarchy) {
+			continue
+		}
+		object, ok := wellField(well, filter.Level).(int64)
+		if !ok {
+			return nil, fmt.Errorf("unknown hierarchy level %q", filter.Level)
+		}
+		period, err := truncateDate(day.DateFact, filter.Period)
+		if err != nil {
+			return nil, err
+		}
+
+		key := rollupKey{int(object), period}
+		acc, ok := groups[key]
+		if !ok {
+			acc = &rollupAccumulator{
+				row: models.ProductionRollup{
+					Level:      filter.Level,
+					Object:     key.object,
+					ObjectName: r.d.objects[key.object].Name,
+					Period:     period,
+				},
+				wells: map[int]bool{},
+			}
+			groups[key] = acc
+		}
+		acc.wells[day.Well] = true
+		acc.days++
+		acc.row.Debit += day.Debit
+		acc.row.EEConsume += day.EEConsume
+		acc.row.Expenses += day.Expenses
+		acc.row.PumpOperating += day.PumpOperating
+	}
+
+	rollup := []models.ProductionRollup{}
+	for _, acc := range groups {
+		acc.row.Wells = len(acc.wells)
+		acc.row.PumpOperating /= float64(acc.days)
+		rollup = append(rollup, acc.row)
+	}
+	sort.Slice(rollup, func(i, j int) bool {
+		if rollup[i].Period != rollup[j].Period {
+			return rollup[i].Period.Before(rollup[j].Period)
+		}
+		return rollup[i].Object < rollup[j].Object
+	})
+	return rollup, nil
+}
+
+func (r *reportRepository) WellCard(ctx context.Context, well int, date civil.Date) (models.WellCard, error) {
+	r.d.mu.RLock()
+	defer r.d.mu.RUnlock()
+
+	w, ok := r.d.wells[well]
+	if !ok {
+		return models.WellCard{}, repository.ErrNotFound
+	}
+	totals := repository.CardPeriod(date)
+	card := models.WellCard{
+		Well: w,
+		NGDU: r.object(w.NGDU),
+		CDNG: r.object(w.CDNG),
+		Kust: r.object(w.Kust),
+		Mest: r.object(w.Mest),
+	}
+
+	for key, history := range r.d.histories {
+		if key.well != well || key.date.After(date) {
+			continue
+		}
+		if card.LatestFact == nil || key.date.After(card.LatestFact.DateFact) {
+			latest := history
+			card.LatestFact = &latest
+		}
+		if !key.date.Before(totals.DateFrom) {
+			totals.Days++
+			totals.Debit += history.Debit
+			totals.EEConsume += history.EEConsume
+			totals.Expenses += history.Expenses
+			totals.PumpOperating += history.PumpOperating
+		}
+	}
+	if plan, ok := r.d.plans[dayKey{well, date}]; ok {
+		card.TodayPlan = &plan
+	}
+	card.Last30Days = totals
+	return card, nil
+}
+
+// object возвращает объект иерархии с именем типа или только ID,
+// если объекта нет в справочнике.
+func (r *reportRepository) object(id int) models.Object {
+	obj, ok := r.d.objects[id]
+	if !ok {
+		return models.Object{ID: id}
+	}
+	obj.TypeName = r.d.objectTypes[obj.Type].Name
+	return obj
+}
+
+func (r *reportRepository) inHierarchy(well models.Well, hierarchy map[string][]int64) bool {
+	for level, ids := range hierarchy {
+		if len(ids) == 0 {
+			continue
+		}
+		value, _ := wellField(well, level).(int64)
+		if !containsID(ids, int(value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// truncateDate возвращает начало дня, недели (понедельник) или месяца, как date_trunc в PostgreSQL.
+func truncateDate(date civil.Date, period string) (civil.Date, error) {
+	switch period {
+	case "day":
+	case "week":
+		date = date.AddDays(-((int(date.Time().Weekday()) + 6) % 7))
+	case "month":
+		date.Day = 1
+	default:
+		return civil.Date{}, fmt.Errorf("unknown rollup period %q", period)
+	}
+	return date, nil
+}
+
+// containsID сообщает, входит ли id в ids. Пустой список означает отсутствие ограничения.
+func containsID(ids []int64, id int) bool {
+	if len(ids) == 0 {
+		return true
+	}
+	for _, v := range ids {
+		if v == int64(id) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,239 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"goAsu/internal/civil"
+	"goAsu/internal/models"
+	"goAsu/internal/query"
+	"goAsu/internal/repository"
+	"time"
+)
+
+type wellDayHistoryRepository struct {
+	d *data
+}
+
+func wellDayHistoryField(history models.WellDayHistory, field string) interface{} {
+	switch field {
+	case "well":
+		return int64(history.Well)
+	case "date_fact":
+		return history.DateFact
+	case "debit":
+		return history.Debit
+	case "ee_consume":
+		return history.EEConsume
+	case "expenses":
+		return history.Expenses
+	case "pump_operating":
+		return history.PumpOperating
+	}
+	return nil
+}
+
+func (r *wellDayHistoryRepository) List(ctx context.Context, q query.List) ([]models.WellDayHistory, int, error) {
+	return r.list(q, nil)
+}
+
+func (r *wellDayHistoryRepository) ListAsOf(ctx context.Context, q query.List, asOf time.Time) ([]models.WellDayHistory, int, error) {
+	return r.list(q, &asOf)
+}
+
+func (r *wellDayHistoryRepository) list(q query.List, asOf *time.Time) ([]models.WellDayHistory, int, error) {
+	r.d.mu.RLock()
+	defer r.d.mu.RUnlock()
+
+	current := r.d.historiesAsOf(asOf)
+	histories := make([]models.WellDayHistory, 0, len(current))
+	for _, history := range current {
+		histories = append(histories, history)
+	}
+	page, total := list(histories, q, wellDayHistoryField)
+	return page, total, nil
+}
+
+func (r *wellDayHistoryRepository) Each(ctx context.Context, q query.List, fn func(models.WellDayHistory) error) error {
+	page, _, err := r.List(ctx, q)
+	if err != nil {
+		return err
+	}
+	return each(page, fn)
+}
+
+func (r *wellDayHistoryRepository) EachAsOf(ctx context.Context, q query.List, asOf time.Time, fn func(models.WellDayHistory) error) error {
+	page, _, err := r.ListAsOf(ctx, q, asOf)
+	if err != nil {
+		return err
+	}
+	return each(page, fn)
+}
+
+func (r *wellDayHistoryRepository) Get(ctx context.Context, well int, dateFact civil.Date) (models.WellDayHistory, error) {
+	r.d.mu.RLock()
+	defer r.d.mu.RUnlock()
+
+	history, ok := r.d.histories[dayKey{well, dateFact}]
+	if !ok {
+		return history, repository.ErrNotFound
+	}
+	return history, nil
+}
+
+func (r *wellDayHistoryRepository) Versions(ctx context.Context, well int, dateFact civil.Date) ([]models.WellDayHistoryVersion, error) {
+	r.d.mu.RLock()
+	defer r.d.mu.RUnlock()
+
+	return append([]models.WellDayHistoryVersion{}, r.d.versions[dayKey{well, dateFact}]...), nil
+}
+
+func (r *wellDayHistoryRepository) Create(ctx context.Context, history models.WellDayHistory) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	key := dayKey{history.Well, history.DateFact}
+	if _, ok := r.d.histories[key]; ok {
+		return fmt.Errorf("history for well %d on %s: %w", history.Well, history.DateFact, repository.ErrConflict)
+	}
+	if err := r.d.checkDayWell("well_day_histories", history.Well); err != nil {
+		return err
+	}
+	r.d.histories[key] = history
+	r.d.version(key, &history)
+	r.d.recordHistory(ctx, nil, &history)
+	return nil
+}
+
+func (r *wellDayHistoryRepository) Update(ctx context.Context, history models.WellDayHistory) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	key := dayKey{history.Well, history.DateFact}
+	old, ok := r.d.histories[key]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	r.d.histories[key] = history
+	r.d.version(key, &history)
+	r.d.recordHistory(ctx, &old, &history)
+	return nil
+}
+
+func (r *wellDayHistoryRepository) Delete(ctx context.Context, well int, dateFact civil.Date) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	key := dayKey{well, dateFact}
+	old, ok := r.d.histories[key]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.d.histories, key)
+	r.d.version(key, nil)
+	r.d.recordHistory(ctx, &old, nil)
+	return nil
+}
+
+func (r *wellDayHistoryRepository) Put(ctx context.Context, history models.WellDayHistory, check func(current *models.WellDayHistory) error) (bool, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	key := dayKey{history.Well, history.DateFact}
+	var old *models.WellDayHistory
+	if stored, ok := r.d.histories[key]; ok {
+		old = &stored
+	}
+	if check != nil {
+		if err := check(old); err != nil {
+			return false, err
+		}
+	}
+	if err := r.d.checkDayWell("well_day_histories", history.Well); err != nil {
+		return false, err
+	}
+	r.d.histories[key] = history
+	r.d.version(key, &history)
+	r.d.recordHistory(ctx, old, &history)
+	return old == nil, nil
+}
+
+func (r *wellDayHistoryRepository) Upsert(ctx context.Context, histories []models.WellDayHistory) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	for _, history := range histories {
+		if err := r.d.checkDayWell("well_day_histories", history.Well); err != nil {
+			return err
+		}
+	}
+	r.d.upsertHistories(ctx, histories)
+	return nil
+}
+
+// upsertHistories сохраняет записи. Вызывается под блокировкой на запись.
+func (d *data) upsertHistories(ctx context.Context, histories []models.WellDayHistory) {
+	for _, history := range histories {
+		key := dayKey{history.Well, history.DateFact}
+		var old *models.WellDayHistory
+		if stored, ok := d.histories[key]; ok {
+			old = &stored
+		}
+		d.histories[key] = history
+		d.version(key, &history)
+		d.recordHistory(ctx, old, &history)
+	}
+}
+
+// version закрывает текущую версию записи key и, если history не nil,
+// открывает новую. Вызывается под блокировкой на запись.
+func (d *data) version(key dayKey, history *models.WellDayHistory) {
+	versions := d.versions[key]
+	n := len(versions)
+	if history != nil && n > 0 && versions[n-1].RecordedTo == nil && versions[n-1].WellDayHistory == *history {
+		return
+	}
+	now := time.Now()
+	if n > 0 && versions[n-1].RecordedTo == nil {
+		versions[n-1].RecordedTo = &now
+	}
+	if history != nil {
+		versions = append(versions, models.WellDayHistoryVersion{WellDayHistory: *history, RecordedFrom: now})
+	}
+	d.versions[key] = versions
+}
+
+// historiesAsOf возвращает записи истории, известные системе в момент asOf,
+// или текущие записи, если asOf не задан.
+func (d *data) historiesAsOf(asOf *time.Time) map[dayKey]models.WellDayHistory {
+	if asOf == nil {
+		return d.histories
+	}
+	histories := map[dayKey]models.WellDayHistory{}
+	for key, versions := range d.versions {
+		for _, v := range versions {
+			if !v.RecordedFrom.After(*asOf) && (v.RecordedTo == nil || v.RecordedTo.After(*asOf)) {
+				histories[key] = v.WellDayHistory
+				break
+			}
+		}
+	}
+	return histories
+}
+
+func (r *wellDayHistoryRepository) UpsertBatch(ctx context.Context, histories []models.WellDayHistory, atomic bool) ([]error, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	errs := make([]error, len(histories))
+	valid := make([]models.WellDayHistory, 0, len(histories))
+	for i, history := range histories {
+		if errs[i] = r.d.checkDayWell("well_day_histories", history.Well); errs[i] == nil {
+			valid = append(valid, history)
+		}
+	}
+	if len(valid) < len(histories) && atomic {
+		return errs, nil
+	}
+	r.d.upsertHistories(ctx, valid)
+	return errs, nil
+}
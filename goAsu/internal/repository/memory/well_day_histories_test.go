@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"goAsu/internal/civil"
+	"goAsu/internal/models"
+	"goAsu/internal/query"
+	"goAsu/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestHistoriesAsOf(t *testing.T) {
+	ctx := context.Background()
+	store := NewDemo()
+	date := civil.Date{Year: 2024, Month: time.December, Day: 7}
+	first := models.WellDayHistory{Well: 4455, DateFact: date, Debit: 10}
+	second := models.WellDayHistory{Well: 4455, DateFact: date, Debit: 12}
+
+	beforeCreate := time.Now()
+	if err := store.Histories.Create(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	afterCreate := time.Now()
+	if err := store.Histories.Update(ctx, second); err != nil {
+		t.Fatal(err)
+	}
+	afterUpdate := time.Now()
+	// Запись без изменений не создает новую версию.
+	if _, err := store.Histories.Put(ctx, second, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Histories.Delete(ctx, 4455, date); err != nil {
+		t.Fatal(err)
+	}
+	afterDelete := time.Now()
+
+	tests := []struct {
+		name  string
+		asOf  time.Time
+		debit float64
+		found bool
+	}{
+		{"before create", beforeCreate, 0, false},
+		{"after create", afterCreate, 10, true},
+		{"after update", afterUpdate, 12, true},
+		{"after delete", afterDelete, 0, false},
+	}
+	q := query.List{Filters: []query.Filter{
+		{Field: "well", Op: query.Eq, Kind: query.Int, Values: []interface{}{int64(4455)}},
+		{Field: "date_fact", Op: query.Eq, Kind: query.Date, Values: []interface{}{date}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			histories, total, err := store.Histories.ListAsOf(ctx, q, tt.asOf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if found := total == 1; found != tt.found {
+				t.Fatalf("found = %v, want %v: %+v", found, tt.found, histories)
+			}
+			if tt.found && histories[0].Debit != tt.debit {
+				t.Errorf("debit = %g, want %g", histories[0].Debit, tt.debit)
+			}
+		})
+	}
+
+	versions, err := store.Histories.Versions(ctx, 4455, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("got %d versions, want 2: %+v", len(versions), versions)
+	}
+	if versions[0].Debit != 10 || versions[1].Debit != 12 {
+		t.Errorf("versions debit = %g, %g; want 10, 12", versions[0].Debit, versions[1].Debit)
+	}
+	if versions[0].RecordedTo == nil || !versions[0].RecordedTo.Equal(versions[1].RecordedFrom) {
+		t.Errorf("first version closed at %v, want %v", versions[0].RecordedTo, versions[1].RecordedFrom)
+	}
+	if versions[1].RecordedTo == nil {
+		t.Error("version of the deleted record is still open")
+	}
+}
+
+func TestPlanFactAsOf(t *testing.T) {
+	ctx := context.Background()
+	store := NewDemo()
+	date := civil.Date{Year: 2024, Month: time.December, Day: 1}
+	before := time.Now()
+	if err := store.Histories.Update(ctx, models.WellDayHistory{Well: 4455, DateFact: date, Debit: 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		asOf *time.Time
+		fact float64
+	}{
+		{"current", nil, 30},
+		{"as of before update", &before, 18},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := store.Reports.PlanFact(ctx, repository.PlanFactFilter{Wells: []int64{4455}, DateFrom: date, DateTo: date, AsOf: tt.asOf})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != 1 || rows[0].Debit.Fact == nil {
+				t.Fatalf("rows = %+v, want one row with a fact", rows)
+			}
+			if *rows[0].Debit.Fact != tt.fact {
+				t.Errorf("fact debit = %g, want %g", *rows[0].Debit.Fact, tt.fact)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"goAsu/internal/civil"
+	"goAsu/internal/models"
+	"goAsu/internal/query"
+	"goAsu/internal/repository"
+)
+
+type wellDayPlanRepository struct {
+	d *data
+}
+
+func wellDayPlanField(plan models.WellDayPlan, field string) interface{} {
+	switch field {
+	case "well":
+		return int64(plan.Well)
+	case "date_plan":
+		return plan.DatePlan
+	case "debit":
+		return plan.Debit
+	case "ee_consume":
+		return plan.EEConsume
+	case "expenses":
+		return plan.Expenses
+	case "pump_operating":
+		return plan.PumpOperating
+	}
+	return nil
+}
+
+func (r *wellDayPlanRepository) List(ctx context.Context, q query.List) ([]models.WellDayPlan, int, error) {
+	r.d.mu.RLock()
+	defer r.d.mu.RUnlock()
+
+	plans := make([]models.WellDayPlan, 0, len(r.d.plans))
+	for _, plan := range r.d.plans {
+		plans = append(plans, plan)
+	}
+	page, total := list(plans, q, wellDayPlanField)
+	return page, total, nil
+}
+
+func (r *wellDayPlanRepository) Each(ctx context.Context, q query.List, fn func(models.WellDayPlan) error) error {
+	page, _, err := r.List(ctx, q)
+	if err != nil {
+		return err
+	}
+	return each(page, fn)
+}
+
+func (r *wellDayPlanRepository) Get(ctx context.Context, well int, datePlan civil.Date) (models.WellDayPlan, error) {
+	r.d.mu.RLock()
+	defer r.d.mu.RUnlock()
+
+	plan, ok := r.d.plans[dayKey{well, datePlan}]
+	if !ok {
+		return plan, repository.ErrNotFound
+	}
+	return plan, nil
+}
+
+func (r *wellDayPlanRepository) Create(ctx context.Context, plan models.WellDayPlan) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	key := dayKey{plan.Well, plan.DatePlan}
+	if _, ok := r.d.plans[key]; ok {
+		return fmt.Errorf("plan for well %d on %s: %w", plan.Well, plan.DatePlan, repository.ErrConflict)
+	}
+	if err := r.d.checkDayWell("well_day_plans", plan.Well); err != nil {
+		return err
+	}
+	r.d.plans[key] = plan
+	r.d.recordPlan(ctx, nil, &plan)
+	return nil
+}
+
+func (r *wellDayPlanRepository) Update(ctx context.Context, plan models.WellDayPlan) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	key := dayKey{plan.Well, plan.DatePlan}
+	old, ok := r.d.plans[key]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	r.d.plans[key] = plan
+	r.d.recordPlan(ctx, &old, &plan)
+	return nil
+}
+
+func (r *wellDayPlanRepository) Delete(ctx context.Context, well int, datePlan civil.Date) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	key := dayKey{well, datePlan}
+	old, ok := r.d.plans[key]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.d.plans, key)
+	r.d.recordPlan(ctx, &old, nil)
+	return nil
+}
+
+func (r *wellDayPlanRepository) Put(ctx context.Context, plan models.WellDayPlan, check func(current *models.WellDayPlan) error) (bool, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	key := dayKey{plan.Well, plan.DatePlan}
+	var old *models.WellDayPlan
+	if stored, ok := r.d.plans[key]; ok {
+		old = &stored
+	}
+	if check != nil {
+		if err := check(old); err != nil {
+			return false, err
+		}
+	}
+	if err := r.d.checkDayWell("well_day_plans", plan.Well); err != nil {
+		return false, err
+	}
+	r.d.plans[key] = plan
+	r.d.recordPlan(ctx, old, &plan)
+	return old == nil, nil
+}
+
+func (r *wellDayPlanRepository) Upsert(ctx context.Context, plans []models.WellDayPlan) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	for _, plan := range plans {
+		if err := r.d.checkDayWell("well_day_plans", plan.Well); err != nil {
+			return err
+		}
+	}
+	r.d.upsertPlans(ctx, plans)
+	return nil
+}
+
+// upsertPlans сохраняет записи. Вызывается под блокировкой на запись.
+func (d *data) upsertPlans(ctx context.Context, plans []models.WellDayPlan) {
+	for _, plan := range plans {
+		key := dayKey{plan.Well, plan.DatePlan}
+		var old *models.WellDayPlan
+		if stored, ok := d.plans[key]; ok {
+			old = &stored
+		}
+		d.plans[key] = plan
+		d.recordPlan(ctx, old, &plan)
+	}
+}
+
+func (r *wellDayPlanRepository) UpsertBatch(ctx context.Context, plans []models.WellDayPlan, atomic bool) ([]error, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	errs := make([]error, len(plans))
+	valid := make([]models.WellDayPlan, 0, len(plans))
+	for i, plan := range plans {
+		if errs[i] = r.d.checkDayWell("well_day_plans", plan.Well); errs[i] == nil {
+			valid = append(valid, plan)
+		}
+	}
+	if len(valid) < len(plans) && atomic {
+		return errs, nil
+	}
+	r.d.upsertPlans(ctx, valid)
+	return errs, nil
+}
@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"goAsu/internal/models"
+	"goAsu/internal/query"
+	"goAsu/internal/repository"
+)
+
+type wellRepository struct {
+	d *data
+}
+
+func wellField(well models.Well, field string) interface{} {
+	switch field {
+	case "well":
+		return int64(well.Well)
+	case "ngdu":
+		return int64(well.NGDU)
+	case "cdng":
+		return int64(well.CDNG)
+	case "kust":
+		return int64(well.Kust)
+	case "mest":
+		return int64(well.Mest)
+	}
+	return nil
+}
+
+func (r *wellRepository) List(ctx context.Context, q query.List) ([]models.Well, int, error) {
+	r.d.mu.RLock()
+	defer r.d.mu.RUnlock()
+
+	wells := make([]models.Well, 0, len(r.d.wells))
+	for _, well := range r.d.wells {
+		wells = append(wells, well)
+	}
+	page, total := list(wells, q, wellField)
+	return page, total, nil
+}
+
+func (r *wellRepository) Each(ctx context.Context, q query.List, fn func(models.Well) error) error {
+	page, _, err := r.List(ctx, q)
+	if err != nil {
+		return err
+	}
+	return each(page, fn)
+}
+
+func (r *wellRepository) Get(ctx context.Context, well int) (models.Well, error) {
+	r.d.mu.RLock()
+	defer r.d.mu.RUnlock()
+
+	w, ok := r.d.wells[well]
+	if !ok {
+		return w, repository.ErrNotFound
+	}
+	return w, nil
+}
+
+func (r *wellRepository) Create(ctx context.Context, well models.Well) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	if _, ok := r.d.wells[well.Well]; ok {
+		return fmt.Errorf("well %d: %w", well.Well, repository.ErrConflict)
+	}
+	if err := r.d.checkWell(well); err != nil {
+		return err
+	}
+	r.d.wells[well.Well] = well
+	r.d.recordWell(ctx, nil, &well)
+	return nil
+}
+
+func (r *wellRepository) Update(ctx context.Context, well models.Well) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	old, ok := r.d.wells[well.Well]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := r.d.checkWell(well); err != nil {
+		return err
+	}
+	r.d.wells[well.Well] = well
+	r.d.recordWell(ctx, &old, &well)
+	return nil
+}
+
+func (r *wellRepository) Patch(ctx context.Context, well int, apply func(w *models.Well) error) (models.Well, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	old, ok := r.d.wells[well]
+	if !ok {
+		return old, repository.ErrNotFound
+	}
+	w := old
+	if err := apply(&w); err != nil {
+		return w, err
+	}
+	w.Well = well
+	if err := r.d.checkWell(w); err != nil {
+		return w, err
+	}
+	r.d.wells[well] = w
+	r.d.recordWell(ctx, &old, &w)
+	return w, nil
+}
+
+func (r *wellRepository) Delete(ctx context.Context, well int) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	old, ok := r.d.wells[well]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if r.d.wellInUse(well) {
+		return repository.ErrInUse
+	}
+	delete(r.d.wells, well)
+	r.d.recordWell(ctx, &old, nil)
+	return nil
+}
+
+func (r *wellRepository) UpsertBatch(ctx context.Context, wells []models.Well, atomic bool) ([]error, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	errs := make([]error, len(wells))
+	failed := false
+	for i, well := range wells {
+		if err := r.d.checkWell(well); err != nil {
+			errs[i], failed = err, true
+		}
+	}
+	if failed && atomic {
+		return errs, nil
+	}
+
+	for i, well := range wells {
+		if errs[i] != nil {
+			continue
+		}
+		var old *models.Well
+		if stored, ok := r.d.wells[well.Well]; ok {
+			old = &stored
+		}
+		r.d.wells[well.Well] = well
+		r.d.recordWell(ctx, old, &well)
+	}
+	return errs, nil
+}
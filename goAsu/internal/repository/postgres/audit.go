@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"goAsu/internal/models"
+	"goAsu/internal/query"
+)
+
+type auditRepository struct {
+	conn
+}
+
+func (r *auditRepository) List(ctx context.Context, q query.List) (_ []models.AuditEntry, _ int, err error) {
+	ctx, done := r.begin(ctx, "audit.List", &err)
+	defer done()
+
+	where, args := q.Where("")
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_log"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	page, args := q.Page(args)
+
+	sqlStatement := `SELECT id, changed_at, entity, entity_id, well, to_char(date, 'YYYY-MM-DD'),
+		action, user_name, request_id, old_value, new_value
+	FROM audit_log`
+	rows, err := r.db.QueryContext(ctx, sqlStatement+where+q.OrderBy("")+page, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var well sql.NullInt64
+		var oldValue, newValue []byte
+		if err := rows.Scan(&entry.ID, &entry.ChangedAt, &entry.Entity, &entry.EntityID, &well, &entry.Date,
+			&entry.Action, &entry.User, &entry.RequestID, &oldValue, &newValue); err != nil {
+			return nil, 0, err
+		}
+		if well.Valid {
+			w := int(well.Int64)
+			entry.Well = &w
+		}
+		entry.Old, entry.New = oldValue, newValue
+		entries = append(entries, entry)
+	}
+	return entries, total, rows.Err()
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"goAsu/internal/civil"
+	"goAsu/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+// batchChunk — наибольшее число записей, сохраняемых одной командой.
+const batchChunk = 500
+
+// errBatchRejected отменяет транзакцию пачки в режиме atomic, когда ошибки
+// всех записей уже найдены.
+var errBatchRejected = errors.New("batch rejected")
+
+// saveBatch сохраняет n записей пачки в одной транзакции и возвращает ошибку
+// по каждой записи. Записи сохраняются частями по batchChunk одной командой
+// chunk(tx, lo, hi); если chunk равен nil или PostgreSQL отвергает часть
+// из-за данных клиента, записи части сохраняются по одной командой one(tx, i),
+// чтобы найти ошибочные. Каждая команда выполняется в точке сохранения,
+// поэтому ошибка записи не прерывает транзакцию. В режиме atomic пачка
+// проверяется до конца и при наличии ошибок откатывается.
+func saveBatch(ctx context.Context, db *sql.DB, n int, atomic bool,
+	chunk func(tx *sql.Tx, lo, hi int) error, one func(tx *sql.Tx, i int) error) ([]error, error) {
+	errs := make([]error, n)
+	failed := false
+	err := inTx(ctx, db, func(tx *sql.Tx) error {
+		for lo := 0; lo < n; lo += batchChunk {
+			hi := min(lo+batchChunk, n)
+			if chunk != nil {
+				err := savepoint(ctx, tx, func() error { return chunk(tx, lo, hi) })
+				if err == nil {
+					continue
+				}
+				if !clientError(err) {
+					return err
+				}
+			}
+			for i := lo; i < hi; i++ {
+				err := savepoint(ctx, tx, func() error { return one(tx, i) })
+				if err != nil && !clientError(err) {
+					return err
+				}
+				if err != nil {
+					errs[i], failed = err, true
+				}
+			}
+		}
+		if failed && atomic {
+			return errBatchRejected
+		}
+		return nil
+	})
+	if errors.Is(err, errBatchRejected) {
+		return errs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// savepoint выполняет fn в точке сохранения транзакции tx и при ошибке
+// откатывает только изменения fn.
+func savepoint(ctx context.Context, tx *sql.Tx, fn func() error) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT batch_item"); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT batch_item"); rbErr != nil {
+			return rbErr
+		}
+		if _, relErr := tx.ExecContext(ctx, "RELEASE SAVEPOINT batch_item"); relErr != nil {
+			return relErr
+		}
+		return translate(err)
+	}
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT batch_item")
+	return err
+}
+
+// clientError сообщает, вызвана ли ошибка данными записи, а не сбоем хранилища.
+func clientError(err error) bool {
+	var constraint *repository.ConstraintError
+	return errors.As(err, &constraint) || errors.Is(err, repository.ErrNotFound)
+}
+
+// dayColumns собирает значения дневных записей по столбцам, чтобы передать
+// их одной командой через unnest.
+type dayColumns struct {
+	wells                                     []int64
+	dates                                     []string
+	debit, eeConsume, expenses, pumpOperating []float64
+}
+
+func (c *dayColumns) add(well int, date civil.Date, debit, eeConsume, expenses, pumpOperating float64) {
+	c.wells = append(c.wells, int64(well))
+	c.dates = append(c.dates, date.String())
+	c.debit = append(c.debit, debit)
+	c.eeConsume = append(c.eeConsume, eeConsume)
+	c.expenses = append(c.expenses, expenses)
+	c.pumpOperating = append(c.pumpOperating, pumpOperating)
+}
+
+func (c *dayColumns) args() []interface{} {
+	return []interface{}{pq.Array(c.wells), pq.Array(c.dates), pq.Array(c.debit),
+		pq.Array(c.eeConsume), pq.Array(c.expenses), pq.Array(c.pumpOperating)}
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goAsu/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+// Коды ошибок PostgreSQL, которые означают ошибку в данных клиента,
+// а не сбой хранилища.
+var constraintErrors = map[pq.ErrorCode]error{
+	"23505": repository.ErrConflict,         // unique_violation
+	"23503": repository.ErrInvalidReference, // foreign_key_violation
+	"23502": repository.ErrInvalidValue,     // not_null_violation
+	"23514": repository.ErrInvalidValue,     // check_violation
+	"22P02": repository.ErrInvalidValue,     // invalid_text_representation
+	"22007": repository.ErrInvalidValue,     // invalid_datetime_format
+	"22008": repository.ErrInvalidValue,     // datetime_field_overflow
+	"22003": repository.ErrInvalidValue,     // numeric_value_out_of_range
+	"22001": repository.ErrInvalidValue,     // string_data_right_truncation
+}
+
+// translate заменяет ошибку PostgreSQL, вызванную данными клиента,
+// на *repository.ConstraintError. Прочие ошибки возвращаются без изменений.
+func translate(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	kind, ok := constraintErrors[pqErr.Code]
+	if !ok {
+		return err
+	}
+	return &repository.ConstraintError{Err: kind, Constraint: pqErr.Constraint, Column: pqErr.Column}
+}
+
+// canceled заменяет ошибку обращения, прерванного из-за отмены ctx, на
+// repository.ErrCanceled или repository.ErrTimeout. PostgreSQL сообщает
+// о прерванном запросе кодом 57014 (query_canceled), а database/sql —
+// ошибкой контекста; в обоих случаях причину показывает ctx.Err().
+func canceled(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil ||
+		errors.Is(err, repository.ErrCanceled) || errors.Is(err, repository.ErrTimeout) {
+		return err
+	}
+	if errors.Is(context.Cause(ctx), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %v", repository.ErrTimeout, err)
+	}
+	return fmt.Errorf("%w: %v", repository.ErrCanceled, err)
+}
+
+// inUse заменяет нарушение внешнего ключа при удалении на repository.ErrInUse:
+// на удаляемую запись ссылаются другие записи.
+func inUse(err error) error {
+	if errors.Is(err, repository.ErrInvalidReference) {
+		return repository.ErrInUse
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"goAsu/internal/models"
+	"goAsu/internal/repository"
+)
+
+type objectTypeRepository struct {
+	conn
+}
+
+func (r *objectTypeRepository) List(ctx context.Context) (_ []models.ObjectType, err error) {
+	ctx, done := r.begin(ctx, "object_types.List", &err)
+	defer done()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT id, name FROM object_types ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objectTypes []models.ObjectType
+	for rows.Next() {
+		var objType models.ObjectType
+		if err := rows.Scan(&objType.ID, &objType.Name); err != nil {
+			return nil, err
+		}
+		objectTypes = append(objectTypes, objType)
+	}
+	return objectTypes, rows.Err()
+}
+
+func (r *objectTypeRepository) Get(ctx context.Context, id int) (_ models.ObjectType, err error) {
+	ctx, done := r.begin(ctx, "object_types.Get", &err)
+	defer done()
+
+	var objType models.ObjectType
+	err = r.db.QueryRowContext(ctx, "SELECT id, name FROM object_types WHERE id=$1", id).Scan(&objType.ID, &objType.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return objType, repository.ErrNotFound
+	}
+	return objType, err
+}
+
+func (r *objectTypeRepository) Create(ctx context.Context, objType *models.ObjectType) (err error) {
+	ctx, done := r.begin(ctx, "object_types.Create", &err)
+	defer done()
+
+	sqlStatement := `INSERT INTO object_types (name) VALUES ($1) RETURNING id`
+	return translate(r.db.QueryRowContext(ctx, sqlStatement, objType.Name).Scan(&objType.ID))
+}
+
+func (r *objectTypeRepository) Update(ctx context.Context, objType models.ObjectType) (err error) {
+	ctx, done := r.begin(ctx, "object_types.Update", &err)
+	defer done()
+
+	sqlStatement := `UPDATE object_types SET name=$1 WHERE id=$2`
+	res, err := r.db.ExecContext(ctx, sqlStatement, objType.Name, objType.ID)
+	if err != nil {
+		return translate(err)
+	}
+	return checkAffected(res)
+}
+
+func (r *objectTypeRepository) Delete(ctx context.Context, id int) (err error) {
+	ctx, done := r.begin(ctx, "object_types.Delete", &err)
+	defer done()
+
+	var referenced bool
+	err = r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM objects WHERE type=$1)`, id).Scan(&referenced)
+	if err != nil {
+		return err
+	}
+	if referenced {
+		return repository.ErrInUse
+	}
+
+	sqlStatement := `DELETE FROM object_types WHERE id=$1`
+	res, err := r.db.ExecContext(ctx, sqlStatement, id)
+	if err != nil {
+		// Объект мог сослаться на тип между проверкой и удалением.
+		return inUse(translate(err))
+	}
+	return checkAffected(res)
+}
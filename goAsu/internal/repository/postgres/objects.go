@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"goAsu/internal/models"
+	"goAsu/internal/query"
+	"goAsu/internal/repository"
+)
+
+type objectRepository struct {
+	conn
+}
+
+func (r *objectRepository) List(ctx context.Context, q query.List) (_ []models.Object, _ int, err error) {
+	ctx, done := r.begin(ctx, "objects.List", &err)
+	defer done()
+
+	where, args := q.Where("o.")
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM objects o"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	var objects []models.Object
+	err = r.Each(ctx, q, func(obj models.Object) error {
+		objects = append(objects, obj)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return objects, total, nil
+}
+
+func (r *objectRepository) Each(ctx context.Context, q query.List, fn func(models.Object) error) (err error) {
+	ctx, started, done := r.beginStream(ctx, "objects.Each", &err)
+	defer done()
+	fn = streamTo(started, fn)
+
+	where, args := q.Where("o.")
+	page, args := q.Page(args)
+
+	sqlStatement := `SELECT o.id, o.name, o.type, COALESCE(t.name, '')
+	FROM objects o LEFT JOIN object_types t ON t.id = o.type`
+	rows, err := r.db.QueryContext(ctx, sqlStatement+where+q.OrderBy("o.")+page, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var obj models.Object
+		if err := rows.Scan(&obj.ID, &obj.Name, &obj.Type, &obj.TypeName); err != nil {
+			return err
+		}
+		if err := fn(obj); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *objectRepository) Get(ctx context.Context, id int) (_ models.Object, err error) {
+	ctx, done := r.begin(ctx, "objects.Get", &err)
+	defer done()
+
+	var obj models.Object
+	sqlStatement := `SELECT o.id, o.name, o.type, COALESCE(t.name, '')
+	FROM objects o LEFT JOIN object_types t ON t.id = o.type WHERE o.id=$1`
+	err = r.db.QueryRowContext(ctx, sqlStatement, id).Scan(&obj.ID, &obj.Name, &obj.Type, &obj.TypeName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return obj, repository.ErrNotFound
+	}
+	return obj, err
+}
+
+func (r *objectRepository) Create(ctx context.Context, obj *models.Object) (err error) {
+	ctx, done := r.begin(ctx, "objects.Create", &err)
+	defer done()
+
+	sqlStatement := `INSERT INTO objects (name, type) VALUES ($1, $2) RETURNING id`
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return tx.QueryRowContext(ctx, sqlStatement, obj.Name, obj.Type).Scan(&obj.ID)
+	})
+}
+
+func (r *objectRepository) Update(ctx context.Context, obj models.Object) (err error) {
+	ctx, done := r.begin(ctx, "objects.Update", &err)
+	defer done()
+
+	sqlStatement := `UPDATE objects SET name=$1, type=$2 WHERE id=$3`
+	res, err := exec(ctx, r.db, sqlStatement, obj.Name, obj.Type, obj.ID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
+
+func (r *objectRepository) Patch(ctx context.Context, id int, apply func(obj *models.Object) error) (obj models.Object, err error) {
+	ctx, done := r.begin(ctx, "objects.Patch", &err)
+	defer done()
+
+	err = inTx(ctx, r.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `SELECT id, name, type FROM objects WHERE id=$1 FOR UPDATE`, id).
+			Scan(&obj.ID, &obj.Name, &obj.Type)
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := apply(&obj); err != nil {
+			return err
+		}
+		return tx.QueryRowContext(ctx, `UPDATE objects SET name=$1, type=$2 WHERE id=$3
+	RETURNING COALESCE((SELECT name FROM object_types WHERE id=$2), '')`, obj.Name, obj.Type, id).Scan(&obj.TypeName)
+	})
+	return obj, err
+}
+
+func (r *objectRepository) Delete(ctx context.Context, id int) (err error) {
+	ctx, done := r.begin(ctx, "objects.Delete", &err)
+	defer done()
+
+	sqlStatement := `DELETE FROM objects WHERE id=$1`
+	res, err := exec(ctx, r.db, sqlStatement, id)
+	if err != nil {
+		return inUse(err)
+	}
+	return checkAffected(res)
+}
+
+// SaveBatch сохраняет объекты по одному: идентификаторы новых объектов
+// выдает последовательность, поэтому многострочная команда не используется.
+func (r *objectRepository) SaveBatch(ctx context.Context, objects []models.Object, atomic bool) (_ []error, err error) {
+	ctx, done := r.begin(ctx, "objects.SaveBatch", &err)
+	defer done()
+
+	one := func(tx *sql.Tx, i int) error {
+		obj := &objects[i]
+		if obj.ID == 0 {
+			return tx.QueryRowContext(ctx, `INSERT INTO objects (name, type) VALUES ($1, $2) RETURNING id`, obj.Name, obj.Type).Scan(&obj.ID)
+		}
+		res, err := tx.ExecContext(ctx, `UPDATE objects SET name=$1, type=$2 WHERE id=$3`, obj.Name, obj.Type, obj.ID)
+		if err != nil {
+			return err
+		}
+		return checkAffected(res)
+	}
+	return saveBatch(ctx, r.db, len(objects), atomic, nil, one)
+}
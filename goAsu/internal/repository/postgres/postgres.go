@@ -0,0 +1,150 @@
+// Package postgres реализует репозитории поверх PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"goAsu/internal/audit"
+	"goAsu/internal/repository"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// New возвращает репозитории, работающие с базой данных db. Каждое
+// обращение к базе данных ограничено временем queryTimeout; для построчной
+// выдачи (методы Each) ограничено только ожидание первой строки. Нулевой
+// queryTimeout снимает ограничение: время задает только контекст вызова.
+func New(db *sql.DB, queryTimeout time.Duration) *repository.Store {
+	c := conn{db: db, timeout: queryTimeout}
+	return &repository.Store{
+		ObjectTypes: &objectTypeRepository{c},
+		Objects:     &objectRepository{c},
+		Wells:       &wellRepository{c},
+		Histories:   &wellDayHistoryRepository{c},
+		Plans:       &wellDayPlanRepository{c},
+		Reports:     &reportRepository{c},
+		Audit:       &auditRepository{c},
+	}
+}
+
+// conn — подключение к базе данных, общее для всех репозиториев.
+type conn struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+var tracer = otel.Tracer("goAsu/internal/repository/postgres")
+
+// begin ограничивает время обращения к базе данных и начинает span
+// трассировки с именем name, например "wells.Get". Возвращенную функцию
+// нужно вызвать по завершении обращения: она освобождает контекст,
+// завершает span и заменяет ошибку, вызванную отменой запроса клиентом
+// или истечением времени, на repository.ErrCanceled или repository.ErrTimeout.
+func (c conn) begin(ctx context.Context, name string, err *error) (context.Context, func()) {
+	ctx, span := c.span(ctx, name)
+	cancel := context.CancelFunc(func() {})
+	if c.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+	}
+	return ctx, func() {
+		c.end(ctx, span, err)
+		cancel()
+	}
+}
+
+// beginStream — begin для методов Each, которые передают строки получателю
+// по мере чтения, например при выгрузке в файл. Время ограничивается только
+// до получения первой строки: started нужно вызывать перед передачей каждой
+// строки получателю. Дальше чтение продолжается, пока его не прервет клиент,
+// поэтому большая выгрузка не обрывается по истечении времени запроса.
+func (c conn) beginStream(ctx context.Context, name string, err *error) (_ context.Context, started, done func()) {
+	ctx, span := c.span(ctx, name)
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := func() {}
+	if c.timeout > 0 {
+		timer := time.AfterFunc(c.timeout, func() { cancel(context.DeadlineExceeded) })
+		stop = func() { timer.Stop() }
+	}
+	return ctx, stop, func() {
+		stop()
+		c.end(ctx, span, err)
+		cancel(nil)
+	}
+}
+
+// streamTo передает строки в fn, вызывая перед этим started (см. beginStream).
+func streamTo[T any](started func(), fn func(T) error) func(T) error {
+	return func(v T) error {
+		started()
+		return fn(v)
+	}
+}
+
+func (c conn) span(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", "postgresql")))
+}
+
+// end завершает span обращения и переводит ошибку отмены (см. begin).
+func (c conn) end(ctx context.Context, span trace.Span, err *error) {
+	*err = canceled(ctx, *err)
+	if *err != nil && !errors.Is(*err, repository.ErrNotFound) {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
+
+// checkAffected возвращает repository.ErrNotFound, если запрос не затронул ни одной строки.
+func checkAffected(res sql.Result) error {
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// setActor передает триггерам журнала изменений автора и идентификатор
+// запроса из ctx. Значения действуют до конца транзакции tx.
+func setActor(ctx context.Context, tx *sql.Tx) error {
+	user, requestID := audit.Actor(ctx)
+	_, err := tx.ExecContext(ctx, `SELECT set_config('goasu.user', $1, true), set_config('goasu.request_id', $2, true)`, user, requestID)
+	return err
+}
+
+// inTx выполняет fn в транзакции, для которой задан автор изменений.
+// Ошибки, вызванные данными клиента, переводятся в ошибки пакета repository.
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := setActor(ctx, tx); err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return translate(err)
+	}
+	return translate(tx.Commit())
+}
+
+// exec выполняет изменяющий запрос в отдельной транзакции с автором изменений.
+func exec(ctx context.Context, db *sql.DB, query string, args ...interface{}) (sql.Result, error) {
+	var res sql.Result
+	err := inTx(ctx, db, func(tx *sql.Tx) error {
+		var err error
+		res, err = tx.ExecContext(ctx, query, args...)
+		return err
+	})
+	return res, err
+}
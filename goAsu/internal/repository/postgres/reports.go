@@ -0,0 +1,226 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"goAsu/internal/civil"
+	"goAsu/internal/models"
+	"goAsu/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+type reportRepository struct {
+	conn
+}
+
+func (r *reportRepository) PlanFact(ctx context.Context, filter repository.PlanFactFilter) (_ []models.PlanFactDeviation, err error) {
+	ctx, done := r.begin(ctx, "reports.PlanFact", &err)
+	defer done()
+
+	args := []interface{}{filter.DateFrom, filter.DateTo}
+	planFilter := "date_plan BETWEEN $1 AND $2"
+	factFilter := "date_fact BETWEEN $1 AND $2"
+	if len(filter.Wells) > 0 {
+		args = append(args, pq.Array(filter.Wells))
+		planFilter += " AND well = ANY($3)"
+		factFilter += " AND well = ANY($3)"
+	}
+	factTable := "well_day_histories"
+	if filter.AsOf != nil {
+		args = append(args, *filter.AsOf)
+		factTable = "well_day_history_versions"
+		factFilter += " AND " + asOfCondition("", len(args))
+	}
+
+	sqlStatement := `SELECT COALESCE(p.well, h.well), COALESCE(p.date_plan, h.date_fact),
+		p.well IS NOT NULL, p.debit, p.ee_consume, p.expenses, p.pump_operating,
+		h.well IS NOT NULL, h.debit, h.ee_consume, h.expenses, h.pump_operating
+	FROM (SELECT well, date_plan, debit, ee_consume, expenses, pump_operating FROM well_day_plans WHERE ` + planFilter + `) p
+	FULL OUTER JOIN (SELECT well, date_fact, debit, ee_consume, expenses, pump_operating FROM ` + factTable + ` WHERE ` + factFilter + `) h
+		ON p.well = h.well AND p.date_plan = h.date_fact
+	ORDER BY 1, 2`
+
+	rows, err := r.db.QueryContext(ctx, sqlStatement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := []models.PlanFactDeviation{}
+	for rows.Next() {
+		var well int
+		var date civil.Date
+		var hasPlan, hasFact bool
+		var plan, fact [4]sql.NullFloat64
+		if err := rows.Scan(&well, &date,
+			&hasPlan, &plan[0], &plan[1], &plan[2], &plan[3],
+			&hasFact, &fact[0], &fact[1], &fact[2], &fact[3]); err != nil {
+			return nil, err
+		}
+
+		var planDay *models.WellDayPlan
+		if hasPlan {
+			planDay = &models.WellDayPlan{Debit: plan[0].Float64, EEConsume: plan[1].Float64, Expenses: plan[2].Float64, PumpOperating: plan[3].Float64}
+		}
+		var factDay *models.WellDayHistory
+		if hasFact {
+			factDay = &models.WellDayHistory{Debit: fact[0].Float64, EEConsume: fact[1].Float64, Expenses: fact[2].Float64, PumpOperating: fact[3].Float64}
+		}
+		report = append(report, repository.NewPlanFactDeviation(well, date, planDay, factDay))
+	}
+	return report, rows.Err()
+}
+
+func (r *reportRepository) WellCard(ctx context.Context, well int, date civil.Date) (_ models.WellCard, err error) {
+	ctx, done := r.begin(ctx, "reports.WellCard", &err)
+	defer done()
+
+	totals := repository.CardPeriod(date)
+
+	// Все части карточки читаются из одного снимка данных.
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return models.WellCard{}, err
+	}
+	defer tx.Rollback()
+
+	sqlStatement := `SELECT w.well, w.ngdu, w.cdng, w.kust, w.mest,
+		h.date_fact, h.debit, h.ee_consume, h.expenses, h.pump_operating,
+		p.date_plan, p.debit, p.ee_consume, p.expenses, p.pump_operating,
+		t.days, t.debit, t.ee_consume, t.expenses, t.pump_operating
+	FROM wells w
+	LEFT JOIN LATERAL (SELECT date_fact, debit, ee_consume, expenses, pump_operating FROM well_day_histories
+		WHERE well = w.well AND date_fact <= $2 ORDER BY date_fact DESC LIMIT 1) h ON true
+	LEFT JOIN well_day_plans p ON p.well = w.well AND p.date_plan = $2
+	CROSS JOIN LATERAL (SELECT COUNT(*), COALESCE(SUM(debit), 0), COALESCE(SUM(ee_consume), 0),
+		COALESCE(SUM(expenses), 0), COALESCE(SUM(pump_operating), 0) FROM well_day_histories
+		WHERE well = w.well AND date_fact BETWEEN $3 AND $2) t(days, debit, ee_consume, expenses, pump_operating)
+	WHERE w.well = $1`
+
+	var card models.WellCard
+	var factDate, planDate sql.Null[civil.Date]
+	var fact, plan [4]sql.NullFloat64
+	err = tx.QueryRowContext(ctx, sqlStatement, well, date, totals.DateFrom).Scan(
+		&card.Well.Well, &card.Well.NGDU, &card.Well.CDNG, &card.Well.Kust, &card.Well.Mest,
+		&factDate, &fact[0], &fact[1], &fact[2], &fact[3],
+		&planDate, &plan[0], &plan[1], &plan[2], &plan[3],
+		&totals.Days, &totals.Debit, &totals.EEConsume, &totals.Expenses, &totals.PumpOperating)
+	if errors.Is(err, sql.ErrNoRows) {
+		return card, repository.ErrNotFound
+	}
+	if err != nil {
+		return card, err
+	}
+	if factDate.Valid {
+		card.LatestFact = &models.WellDayHistory{Well: well, DateFact: factDate.V,
+			Debit: fact[0].Float64, EEConsume: fact[1].Float64, Expenses: fact[2].Float64, PumpOperating: fact[3].Float64}
+	}
+	if planDate.Valid {
+		card.TodayPlan = &models.WellDayPlan{Well: well, DatePlan: planDate.V,
+			Debit: plan[0].Float64, EEConsume: plan[1].Float64, Expenses: plan[2].Float64, PumpOperating: plan[3].Float64}
+	}
+	card.Last30Days = totals
+
+	objects := map[int]models.Object{}
+	ids := []int64{int64(card.Well.NGDU), int64(card.Well.CDNG), int64(card.Well.Kust), int64(card.Well.Mest)}
+	rows, err := tx.QueryContext(ctx, `SELECT o.id, o.name, o.type, COALESCE(t.name, '')
+	FROM objects o LEFT JOIN object_types t ON t.id = o.type WHERE o.id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return card, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var obj models.Object
+		if err := rows.Scan(&obj.ID, &obj.Name, &obj.Type, &obj.TypeName); err != nil {
+			return card, err
+		}
+		objects[obj.ID] = obj
+	}
+	if err := rows.Err(); err != nil {
+		return card, err
+	}
+
+	object := func(id int) models.Object {
+		if obj, ok := objects[id]; ok {
+			return obj
+		}
+		return models.Object{ID: id}
+	}
+	card.NGDU, card.CDNG, card.Kust, card.Mest = object(card.Well.NGDU), object(card.Well.CDNG), object(card.Well.Kust), object(card.Well.Mest)
+	return card, nil
+}
+
+// hierarchyColumns сопоставляет уровень иерархии со столбцом таблицы wells.
+var hierarchyColumns = map[string]string{
+	"ngdu": "ngdu",
+	"cdng": "cdng",
+	"kust": "kust",
+	"mest": "mest",
+}
+
+// rollupSources сопоставляет источник данных с таблицей и столбцом даты.
+var rollupSources = map[string][2]string{
+	"fact": {"well_day_histories", "date_fact"},
+	"plan": {"well_day_plans", "date_plan"},
+}
+
+func (r *reportRepository) Rollup(ctx context.Context, filter repository.RollupFilter) (_ []models.ProductionRollup, err error) {
+	ctx, done := r.begin(ctx, "reports.Rollup", &err)
+	defer done()
+
+	column, ok := hierarchyColumns[filter.Level]
+	if !ok {
+		return nil, fmt.Errorf("unknown hierarchy level %q", filter.Level)
+	}
+	source, ok := rollupSources[filter.Source]
+	if !ok {
+		return nil, fmt.Errorf("unknown rollup source %q", filter.Source)
+	}
+
+	table, dateColumn := source[0], source[1]
+	args := []interface{}{filter.Period, filter.DateFrom, filter.DateTo}
+	where := "d." + dateColumn + " BETWEEN $2 AND $3"
+	if filter.AsOf != nil {
+		if filter.Source != "fact" {
+			return nil, fmt.Errorf("as-of queries are supported only for fact data")
+		}
+		args = append(args, *filter.AsOf)
+		table = "well_day_history_versions"
+		where += " AND " + asOfCondition("d.", len(args))
+	}
+	for _, level := range []string{"ngdu", "cdng", "kust", "mest"} {
+		if ids := filter.Hierarchy[level]; len(ids) > 0 {
+			args = append(args, pq.Array(ids))
+			where += fmt.Sprintf(" AND w.%s = ANY($%d)", hierarchyColumns[level], len(args))
+		}
+	}
+
+	sqlStatement := `SELECT w.` + column + `, COALESCE(o.name, ''), date_trunc($1, d.` + dateColumn + `)::date,
+		COUNT(DISTINCT d.well), SUM(d.debit), SUM(d.ee_consume), SUM(d.expenses), AVG(d.pump_operating)
+	FROM ` + table + ` d
+	JOIN wells w ON w.well = d.well
+	LEFT JOIN objects o ON o.id = w.` + column + `
+	WHERE ` + where + `
+	GROUP BY 1, 2, 3
+	ORDER BY 3, 1`
+
+	rows, err := r.db.QueryContext(ctx, sqlStatement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rollup := []models.ProductionRollup{}
+	for rows.Next() {
+		row := models.ProductionRollup{Level: filter.Level}
+		if err := rows.Scan(&row.Object, &row.ObjectName, &row.Period,
+			&row.Wells, &row.Debit, &row.EEConsume, &row.Expenses, &row.PumpOperating); err != nil {
+			return nil, err
+		}
+		rollup = append(rollup, row)
+	}
+	return rollup, rows.Err()
+}
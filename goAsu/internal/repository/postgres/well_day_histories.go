@@ -0,0 +1,286 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"goAsu/internal/civil"
+	"goAsu/internal/models"
+	"goAsu/internal/query"
+	"goAsu/internal/repository"
+	"time"
+)
+
+type wellDayHistoryRepository struct {
+	conn
+}
+
+func (r *wellDayHistoryRepository) List(ctx context.Context, q query.List) (_ []models.WellDayHistory, _ int, err error) {
+	ctx, done := r.begin(ctx, "well_day_histories.List", &err)
+	defer done()
+
+	return r.list(ctx, q, nil)
+}
+
+func (r *wellDayHistoryRepository) ListAsOf(ctx context.Context, q query.List, asOf time.Time) (_ []models.WellDayHistory, _ int, err error) {
+	ctx, done := r.begin(ctx, "well_day_histories.ListAsOf", &err)
+	defer done()
+
+	return r.list(ctx, q, &asOf)
+}
+
+func (r *wellDayHistoryRepository) Each(ctx context.Context, q query.List, fn func(models.WellDayHistory) error) (err error) {
+	ctx, started, done := r.beginStream(ctx, "well_day_histories.Each", &err)
+	defer done()
+
+	return r.each(ctx, q, nil, streamTo(started, fn))
+}
+
+func (r *wellDayHistoryRepository) EachAsOf(ctx context.Context, q query.List, asOf time.Time, fn func(models.WellDayHistory) error) (err error) {
+	ctx, started, done := r.beginStream(ctx, "well_day_histories.EachAsOf", &err)
+	defer done()
+
+	return r.each(ctx, q, &asOf, streamTo(started, fn))
+}
+
+func (r *wellDayHistoryRepository) Get(ctx context.Context, well int, dateFact civil.Date) (_ models.WellDayHistory, err error) {
+	ctx, done := r.begin(ctx, "well_day_histories.Get", &err)
+	defer done()
+
+	var history models.WellDayHistory
+	sqlStatement := `SELECT well, date_fact, debit, ee_consume, expenses, pump_operating FROM well_day_histories WHERE well=$1 AND date_fact=$2`
+	err = r.db.QueryRowContext(ctx, sqlStatement, well, dateFact).
+		Scan(&history.Well, &history.DateFact, &history.Debit, &history.EEConsume, &history.Expenses, &history.PumpOperating)
+	if errors.Is(err, sql.ErrNoRows) {
+		return history, repository.ErrNotFound
+	}
+	return history, err
+}
+
+func (r *wellDayHistoryRepository) list(ctx context.Context, q query.List, asOf *time.Time) ([]models.WellDayHistory, int, error) {
+	from, args := historySource(q, asOf)
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+from, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	var histories []models.WellDayHistory
+	err := r.each(ctx, q, asOf, func(history models.WellDayHistory) error {
+		histories = append(histories, history)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return histories, total, nil
+}
+
+func (r *wellDayHistoryRepository) each(ctx context.Context, q query.List, asOf *time.Time, fn func(models.WellDayHistory) error) error {
+	from, args := historySource(q, asOf)
+	page, args := q.Page(args)
+
+	rows, err := r.db.QueryContext(ctx, "SELECT well, date_fact, debit, ee_consume, expenses, pump_operating FROM "+from+q.OrderBy("")+page, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var history models.WellDayHistory
+		if err := rows.Scan(&history.Well, &history.DateFact, &history.Debit, &history.EEConsume, &history.Expenses, &history.PumpOperating); err != nil {
+			return err
+		}
+		if err := fn(history); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// historySource возвращает таблицу с условием выборки q: текущие записи
+// или, если задан asOf, версии, действовавшие в этот момент.
+func historySource(q query.List, asOf *time.Time) (string, []interface{}) {
+	where, args := q.Where("")
+	if asOf == nil {
+		return "well_day_histories" + where, args
+	}
+	args = append(args, *asOf)
+	cond := asOfCondition("", len(args))
+	if where == "" {
+		where = " WHERE " + cond
+	} else {
+		where += " AND " + cond
+	}
+	return "well_day_history_versions" + where, args
+}
+
+// asOfCondition возвращает условие отбора версий, действовавших в момент,
+// переданный параметром с номером n.
+func asOfCondition(prefix string, n int) string {
+	return fmt.Sprintf("%[1]srecorded_from <= $%[2]d AND (%[1]srecorded_to IS NULL OR %[1]srecorded_to > $%[2]d)", prefix, n)
+}
+
+func (r *wellDayHistoryRepository) Versions(ctx context.Context, well int, dateFact civil.Date) (_ []models.WellDayHistoryVersion, err error) {
+	ctx, done := r.begin(ctx, "well_day_histories.Versions", &err)
+	defer done()
+
+	sqlStatement := `SELECT well, date_fact, debit, ee_consume, expenses, pump_operating, recorded_from, recorded_to
+	FROM well_day_history_versions WHERE well=$1 AND date_fact=$2 ORDER BY recorded_from`
+	rows, err := r.db.QueryContext(ctx, sqlStatement, well, dateFact)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []models.WellDayHistoryVersion{}
+	for rows.Next() {
+		var v models.WellDayHistoryVersion
+		var recordedTo sql.NullTime
+		if err := rows.Scan(&v.Well, &v.DateFact, &v.Debit, &v.EEConsume, &v.Expenses, &v.PumpOperating, &v.RecordedFrom, &recordedTo); err != nil {
+			return nil, err
+		}
+		if recordedTo.Valid {
+			v.RecordedTo = &recordedTo.Time
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+func (r *wellDayHistoryRepository) Create(ctx context.Context, history models.WellDayHistory) (err error) {
+	ctx, done := r.begin(ctx, "well_day_histories.Create", &err)
+	defer done()
+
+	sqlStatement := `INSERT INTO well_day_histories (well, date_fact, debit, ee_consume, expenses, pump_operating) VALUES ($1, $2, $3, $4, $5, $6)`
+	res, err := exec(ctx, r.db, sqlStatement, history.Well, history.DateFact, history.Debit, history.EEConsume, history.Expenses, history.PumpOperating)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
+
+func (r *wellDayHistoryRepository) Update(ctx context.Context, history models.WellDayHistory) (err error) {
+	ctx, done := r.begin(ctx, "well_day_histories.Update", &err)
+	defer done()
+
+	sqlStatement := `UPDATE well_day_histories SET debit=$1, ee_consume=$2, expenses=$3, pump_operating=$4 WHERE well=$5 AND date_fact=$6`
+	res, err := exec(ctx, r.db, sqlStatement, history.Debit, history.EEConsume, history.Expenses, history.PumpOperating, history.Well, history.DateFact)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
+
+func (r *wellDayHistoryRepository) Delete(ctx context.Context, well int, dateFact civil.Date) (err error) {
+	ctx, done := r.begin(ctx, "well_day_histories.Delete", &err)
+	defer done()
+
+	sqlStatement := `DELETE FROM well_day_histories WHERE well=$1 AND date_fact=$2`
+	res, err := exec(ctx, r.db, sqlStatement, well, dateFact)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
+
+func (r *wellDayHistoryRepository) Put(ctx context.Context, history models.WellDayHistory, check func(current *models.WellDayHistory) error) (created bool, err error) {
+	ctx, done := r.begin(ctx, "well_day_histories.Put", &err)
+	defer done()
+
+	err = inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if check == nil {
+			// xmax = 0 только у вставленной, а не обновленной строки.
+			return tx.QueryRowContext(ctx, upsertHistorySQL+` RETURNING xmax = 0`, history.Well, history.DateFact, history.Debit, history.EEConsume, history.Expenses, history.PumpOperating).
+				Scan(&created)
+		}
+		for {
+			// Блокировка строки не дает изменить запись между проверкой и записью.
+			var stored models.WellDayHistory
+			err := tx.QueryRowContext(ctx, `SELECT well, date_fact, debit, ee_consume, expenses, pump_operating FROM well_day_histories WHERE well=$1 AND date_fact=$2 FOR UPDATE`, history.Well, history.DateFact).
+				Scan(&stored.Well, &stored.DateFact, &stored.Debit, &stored.EEConsume, &stored.Expenses, &stored.PumpOperating)
+			switch {
+			case err == nil:
+				if err := check(&stored); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, upsertHistorySQL, history.Well, history.DateFact, history.Debit, history.EEConsume, history.Expenses, history.PumpOperating)
+				return err
+			case !errors.Is(err, sql.ErrNoRows):
+				return err
+			}
+			if err := check(nil); err != nil {
+				return err
+			}
+			// Отсутствующую строку заблокировать нельзя: если параллельный
+			// запрос успел ее вставить, вставка ничего не делает, и проверка
+			// повторяется уже с его записью.
+			res, err := tx.ExecContext(ctx, `INSERT INTO well_day_histories (well, date_fact, debit, ee_consume, expenses, pump_operating)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (well, date_fact) DO NOTHING`, history.Well, history.DateFact, history.Debit, history.EEConsume, history.Expenses, history.PumpOperating)
+			if err != nil {
+				return err
+			}
+			if n, err := res.RowsAffected(); err != nil || n > 0 {
+				created = n > 0
+				return err
+			}
+		}
+	})
+	return created, err
+}
+
+func (r *wellDayHistoryRepository) Upsert(ctx context.Context, histories []models.WellDayHistory) (err error) {
+	ctx, done := r.begin(ctx, "well_day_histories.Upsert", &err)
+	defer done()
+
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, upsertHistorySQL)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, history := range histories {
+			if _, err := stmt.ExecContext(ctx, history.Well, history.DateFact, history.Debit, history.EEConsume, history.Expenses, history.PumpOperating); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// upsertHistorySQL сохраняет одну запись, заменяя существующую запись с тем же ключом.
+const upsertHistorySQL = `INSERT INTO well_day_histories (well, date_fact, debit, ee_consume, expenses, pump_operating)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (well, date_fact) DO UPDATE SET
+	debit = EXCLUDED.debit,
+	ee_consume = EXCLUDED.ee_consume,
+	expenses = EXCLUDED.expenses,
+	pump_operating = EXCLUDED.pump_operating`
+
+func (r *wellDayHistoryRepository) UpsertBatch(ctx context.Context, histories []models.WellDayHistory, atomic bool) (_ []error, err error) {
+	ctx, done := r.begin(ctx, "well_day_histories.UpsertBatch", &err)
+	defer done()
+
+	chunk := func(tx *sql.Tx, lo, hi int) error {
+		var cols dayColumns
+		for _, history := range histories[lo:hi] {
+			cols.add(history.Well, history.DateFact, history.Debit, history.EEConsume, history.Expenses, history.PumpOperating)
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO well_day_histories (well, date_fact, debit, ee_consume, expenses, pump_operating)
+		SELECT * FROM unnest($1::integer[], $2::date[], $3::double precision[], $4::double precision[], $5::double precision[], $6::double precision[])
+		ON CONFLICT (well, date_fact) DO UPDATE SET
+			debit = EXCLUDED.debit,
+			ee_consume = EXCLUDED.ee_consume,
+			expenses = EXCLUDED.expenses,
+			pump_operating = EXCLUDED.pump_operating`, cols.args()...)
+		return err
+	}
+	one := func(tx *sql.Tx, i int) error {
+		history := histories[i]
+		_, err := tx.ExecContext(ctx, upsertHistorySQL, history.Well, history.DateFact, history.Debit, history.EEConsume, history.Expenses, history.PumpOperating)
+		return err
+	}
+	return saveBatch(ctx, r.db, len(histories), atomic, chunk, one)
+}
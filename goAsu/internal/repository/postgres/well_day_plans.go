@@ -0,0 +1,213 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"goAsu/internal/civil"
+	"goAsu/internal/models"
+	"goAsu/internal/query"
+	"goAsu/internal/repository"
+)
+
+type wellDayPlanRepository struct {
+	conn
+}
+
+func (r *wellDayPlanRepository) List(ctx context.Context, q query.List) (_ []models.WellDayPlan, _ int, err error) {
+	ctx, done := r.begin(ctx, "well_day_plans.List", &err)
+	defer done()
+
+	where, args := q.Where("")
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM well_day_plans"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	var plans []models.WellDayPlan
+	err = r.Each(ctx, q, func(plan models.WellDayPlan) error {
+		plans = append(plans, plan)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return plans, total, nil
+}
+
+func (r *wellDayPlanRepository) Each(ctx context.Context, q query.List, fn func(models.WellDayPlan) error) (err error) {
+	ctx, started, done := r.beginStream(ctx, "well_day_plans.Each", &err)
+	defer done()
+	fn = streamTo(started, fn)
+
+	where, args := q.Where("")
+	page, args := q.Page(args)
+
+	rows, err := r.db.QueryContext(ctx, "SELECT well, date_plan, debit, ee_consume, expenses, pump_operating FROM well_day_plans"+where+q.OrderBy("")+page, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var plan models.WellDayPlan
+		if err := rows.Scan(&plan.Well, &plan.DatePlan, &plan.Debit, &plan.EEConsume, &plan.Expenses, &plan.PumpOperating); err != nil {
+			return err
+		}
+		if err := fn(plan); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *wellDayPlanRepository) Get(ctx context.Context, well int, datePlan civil.Date) (_ models.WellDayPlan, err error) {
+	ctx, done := r.begin(ctx, "well_day_plans.Get", &err)
+	defer done()
+
+	var plan models.WellDayPlan
+	sqlStatement := `SELECT well, date_plan, debit, ee_consume, expenses, pump_operating FROM well_day_plans WHERE well=$1 AND date_plan=$2`
+	err = r.db.QueryRowContext(ctx, sqlStatement, well, datePlan).
+		Scan(&plan.Well, &plan.DatePlan, &plan.Debit, &plan.EEConsume, &plan.Expenses, &plan.PumpOperating)
+	if errors.Is(err, sql.ErrNoRows) {
+		return plan, repository.ErrNotFound
+	}
+	return plan, err
+}
+
+func (r *wellDayPlanRepository) Create(ctx context.Context, plan models.WellDayPlan) (err error) {
+	ctx, done := r.begin(ctx, "well_day_plans.Create", &err)
+	defer done()
+
+	sqlStatement := `INSERT INTO well_day_plans (well, date_plan, debit, ee_consume, expenses, pump_operating) VALUES ($1, $2, $3, $4, $5, $6)`
+	res, err := exec(ctx, r.db, sqlStatement, plan.Well, plan.DatePlan, plan.Debit, plan.EEConsume, plan.Expenses, plan.PumpOperating)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
+
+func (r *wellDayPlanRepository) Update(ctx context.Context, plan models.WellDayPlan) (err error) {
+	ctx, done := r.begin(ctx, "well_day_plans.Update", &err)
+	defer done()
+
+	sqlStatement := `UPDATE well_day_plans SET debit=$1, ee_consume=$2, expenses=$3, pump_operating=$4 WHERE well=$5 AND date_plan=$6`
+	res, err := exec(ctx, r.db, sqlStatement, plan.Debit, plan.EEConsume, plan.Expenses, plan.PumpOperating, plan.Well, plan.DatePlan)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
+
+func (r *wellDayPlanRepository) Delete(ctx context.Context, well int, datePlan civil.Date) (err error) {
+	ctx, done := r.begin(ctx, "well_day_plans.Delete", &err)
+	defer done()
+
+	sqlStatement := `DELETE FROM well_day_plans WHERE well=$1 AND date_plan=$2`
+	res, err := exec(ctx, r.db, sqlStatement, well, datePlan)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
+
+func (r *wellDayPlanRepository) Put(ctx context.Context, plan models.WellDayPlan, check func(current *models.WellDayPlan) error) (created bool, err error) {
+	ctx, done := r.begin(ctx, "well_day_plans.Put", &err)
+	defer done()
+
+	err = inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if check == nil {
+			// xmax = 0 только у вставленной, а не обновленной строки.
+			return tx.QueryRowContext(ctx, upsertPlanSQL+` RETURNING xmax = 0`, plan.Well, plan.DatePlan, plan.Debit, plan.EEConsume, plan.Expenses, plan.PumpOperating).
+				Scan(&created)
+		}
+		for {
+			// Блокировка строки не дает изменить запись между проверкой и записью.
+			var stored models.WellDayPlan
+			err := tx.QueryRowContext(ctx, `SELECT well, date_plan, debit, ee_consume, expenses, pump_operating FROM well_day_plans WHERE well=$1 AND date_plan=$2 FOR UPDATE`, plan.Well, plan.DatePlan).
+				Scan(&stored.Well, &stored.DatePlan, &stored.Debit, &stored.EEConsume, &stored.Expenses, &stored.PumpOperating)
+			switch {
+			case err == nil:
+				if err := check(&stored); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, upsertPlanSQL, plan.Well, plan.DatePlan, plan.Debit, plan.EEConsume, plan.Expenses, plan.PumpOperating)
+				return err
+			case !errors.Is(err, sql.ErrNoRows):
+				return err
+			}
+			if err := check(nil); err != nil {
+				return err
+			}
+			// Отсутствующую строку заблокировать нельзя: если параллельный
+			// запрос успел ее вставить, вставка ничего не делает, и проверка
+			// повторяется уже с его записью.
+			res, err := tx.ExecContext(ctx, `INSERT INTO well_day_plans (well, date_plan, debit, ee_consume, expenses, pump_operating)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (well, date_plan) DO NOTHING`, plan.Well, plan.DatePlan, plan.Debit, plan.EEConsume, plan.Expenses, plan.PumpOperating)
+			if err != nil {
+				return err
+			}
+			if n, err := res.RowsAffected(); err != nil || n > 0 {
+				created = n > 0
+				return err
+			}
+		}
+	})
+	return created, err
+}
+
+func (r *wellDayPlanRepository) Upsert(ctx context.Context, plans []models.WellDayPlan) (err error) {
+	ctx, done := r.begin(ctx, "well_day_plans.Upsert", &err)
+	defer done()
+
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, upsertPlanSQL)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, plan := range plans {
+			if _, err := stmt.ExecContext(ctx, plan.Well, plan.DatePlan, plan.Debit, plan.EEConsume, plan.Expenses, plan.PumpOperating); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// upsertPlanSQL сохраняет одну запись, заменяя существующую запись с тем же ключом.
+const upsertPlanSQL = `INSERT INTO well_day_plans (well, date_plan, debit, ee_consume, expenses, pump_operating)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (well, date_plan) DO UPDATE SET
+	debit = EXCLUDED.debit,
+	ee_consume = EXCLUDED.ee_consume,
+	expenses = EXCLUDED.expenses,
+	pump_operating = EXCLUDED.pump_operating`
+
+func (r *wellDayPlanRepository) UpsertBatch(ctx context.Context, plans []models.WellDayPlan, atomic bool) (_ []error, err error) {
+	ctx, done := r.begin(ctx, "well_day_plans.UpsertBatch", &err)
+	defer done()
+
+	chunk := func(tx *sql.Tx, lo, hi int) error {
+		var cols dayColumns
+		for _, plan := range plans[lo:hi] {
+			cols.add(plan.Well, plan.DatePlan, plan.Debit, plan.EEConsume, plan.Expenses, plan.PumpOperating)
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO well_day_plans (well, date_plan, debit, ee_consume, expenses, pump_operating)
+		SELECT * FROM unnest($1::integer[], $2::date[], $3::double precision[], $4::double precision[], $5::double precision[], $6::double precision[])
+		ON CONFLICT (well, date_plan) DO UPDATE SET
+			debit = EXCLUDED.debit,
+			ee_consume = EXCLUDED.ee_consume,
+			expenses = EXCLUDED.expenses,
+			pump_operating = EXCLUDED.pump_operating`, cols.args()...)
+		return err
+	}
+	one := func(tx *sql.Tx, i int) error {
+		plan := plans[i]
+		_, err := tx.ExecContext(ctx, upsertPlanSQL, plan.Well, plan.DatePlan, plan.Debit, plan.EEConsume, plan.Expenses, plan.PumpOperating)
+		return err
+	}
+	return saveBatch(ctx, r.db, len(plans), atomic, chunk, one)
+}
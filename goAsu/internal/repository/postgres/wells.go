@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"goAsu/internal/models"
+	"goAsu/internal/query"
+	"goAsu/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+type wellRepository struct {
+	conn
+}
+
+func (r *wellRepository) List(ctx context.Context, q query.List) (_ []models.Well, _ int, err error) {
+	ctx, done := r.begin(ctx, "wells.List", &err)
+	defer done()
+
+	where, args := q.Where("")
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM wells"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	var wells []models.Well
+	err = r.Each(ctx, q, func(well models.Well) error {
+		wells = append(wells, well)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return wells, total, nil
+}
+
+func (r *wellRepository) Each(ctx context.Context, q query.List, fn func(models.Well) error) (err error) {
+	ctx, started, done := r.beginStream(ctx, "wells.Each", &err)
+	defer done()
+	fn = streamTo(started, fn)
+
+	where, args := q.Where("")
+	page, args := q.Page(args)
+
+	rows, err := r.db.QueryContext(ctx, "SELECT well, ngdu, cdng, kust, mest FROM wells"+where+q.OrderBy("")+page, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var well models.Well
+		if err := rows.Scan(&well.Well, &well.NGDU, &well.CDNG, &well.Kust, &well.Mest); err != nil {
+			return err
+		}
+		if err := fn(well); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *wellRepository) Get(ctx context.Context, well int) (_ models.Well, err error) {
+	ctx, done := r.begin(ctx, "wells.Get", &err)
+	defer done()
+
+	var w models.Well
+	err = r.db.QueryRowContext(ctx, "SELECT well, ngdu, cdng, kust, mest FROM wells WHERE well=$1", well).
+		Scan(&w.Well, &w.NGDU, &w.CDNG, &w.Kust, &w.Mest)
+	if errors.Is(err, sql.ErrNoRows) {
+		return w, repository.ErrNotFound
+	}
+	return w, err
+}
+
+func (r *wellRepository) Create(ctx context.Context, well models.Well) (err error) {
+	ctx, done := r.begin(ctx, "wells.Create", &err)
+	defer done()
+
+	sqlStatement := `INSERT INTO wells (well, ngdu, cdng, kust, mest) VALUES ($1, $2, $3, $4, $5)`
+	res, err := exec(ctx, r.db, sqlStatement, well.Well, well.NGDU, well.CDNG, well.Kust, well.Mest)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
+
+func (r *wellRepository) Update(ctx context.Context, well models.Well) (err error) {
+	ctx, done := r.begin(ctx, "wells.Update", &err)
+	defer done()
+
+	sqlStatement := `UPDATE wells SET ngdu=$1, cdng=$2, kust=$3, mest=$4 WHERE well=$5`
+	res, err := exec(ctx, r.db, sqlStatement, well.NGDU, well.CDNG, well.Kust, well.Mest, well.Well)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
+
+func (r *wellRepository) Patch(ctx context.Context, well int, apply func(w *models.Well) error) (w models.Well, err error) {
+	ctx, done := r.begin(ctx, "wells.Patch", &err)
+	defer done()
+
+	err = inTx(ctx, r.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, "SELECT well, ngdu, cdng, kust, mest FROM wells WHERE well=$1 FOR UPDATE", well).
+			Scan(&w.Well, &w.NGDU, &w.CDNG, &w.Kust, &w.Mest)
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := apply(&w); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE wells SET ngdu=$1, cdng=$2, kust=$3, mest=$4 WHERE well=$5`, w.NGDU, w.CDNG, w.Kust, w.Mest, well)
+		return err
+	})
+	return w, err
+}
+
+func (r *wellRepository) Delete(ctx context.Context, well int) (err error) {
+	ctx, done := r.begin(ctx, "wells.Delete", &err)
+	defer done()
+
+	sqlStatement := `DELETE FROM wells WHERE well=$1`
+	res, err := exec(ctx, r.db, sqlStatement, well)
+	if err != nil {
+		return inUse(err)
+	}
+	return checkAffected(res)
+}
+
+func (r *wellRepository) UpsertBatch(ctx context.Context, wells []models.Well, atomic bool) (_ []error, err error) {
+	ctx, done := r.begin(ctx, "wells.UpsertBatch", &err)
+	defer done()
+
+	chunk := func(tx *sql.Tx, lo, hi int) error {
+		cols := make([][]int64, 5)
+		for _, well := range wells[lo:hi] {
+			for j, v := range []int{well.Well, well.NGDU, well.CDNG, well.Kust, well.Mest} {
+				cols[j] = append(cols[j], int64(v))
+			}
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO wells (well, ngdu, cdng, kust, mest)
+		SELECT * FROM unnest($1::integer[], $2::integer[], $3::integer[], $4::integer[], $5::integer[])
+		ON CONFLICT (well) DO UPDATE SET
+			ngdu = EXCLUDED.ngdu, cdng = EXCLUDED.cdng, kust = EXCLUDED.kust, mest = EXCLUDED.mest`,
+			pq.Array(cols[0]), pq.Array(cols[1]), pq.Array(cols[2]), pq.Array(cols[3]), pq.Array(cols[4]))
+		return err
+	}
+	one := func(tx *sql.Tx, i int) error {
+		well := wells[i]
+		_, err := tx.ExecContext(ctx, `INSERT INTO wells (well, ngdu, cdng, kust, mest) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (well) DO UPDATE SET
+			ngdu = EXCLUDED.ngdu, cdng = EXCLUDED.cdng, kust = EXCLUDED.kust, mest = EXCLUDED.mest`,
+			well.Well, well.NGDU, well.CDNG, well.Kust, well.Mest)
+		return err
+	}
+	return saveBatch(ctx, r.db, len(wells), atomic, chunk, one)
+}
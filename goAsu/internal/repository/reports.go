@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"goAsu/internal/civil"
+	"goAsu/internal/models"
+)
+
+// CardDays — число дней, за которые карточка скважины подводит итоги.
+const CardDays = 30
+
+// CardPeriod возвращает период итогов карточки скважины: CardDays дней,
+// последний из которых — date. Итоги заполняются реализацией хранилища.
+func CardPeriod(date civil.Date) models.WellDayTotals {
+	return models.WellDayTotals{DateFrom: date.AddDays(1 - CardDays), DateTo: date}
+}
+
+// NewPlanFactDeviation строит строку отчета "план-факт" по плановому и
+// фактическому дню. Отсутствующая запись передается как nil.
+func NewPlanFactDeviation(well int, date civil.Date, plan *models.WellDayPlan, fact *models.WellDayHistory) models.PlanFactDeviation {
+	row := models.PlanFactDeviation{Well: well, Date: date}
+	switch {
+	case plan != nil && fact != nil:
+		row.Status = "both"
+	case plan != nil:
+		row.Status = "plan_only"
+	default:
+		row.Status = "fact_only"
+	}
+
+	var planValues, factValues *[4]float64
+	if plan != nil {
+		planValues = &[4]float64{plan.Debit, plan.EEConsume, plan.Expenses, plan.PumpOperating}
+	}
+	if fact != nil {
+		factValues = &[4]float64{fact.Debit, fact.EEConsume, fact.Expenses, fact.PumpOperating}
+	}
+	row.Debit = newMetricDeviation(planValues, factValues, 0)
+	row.EEConsume = newMetricDeviation(planValues, factValues, 1)
+	row.Expenses = newMetricDeviation(planValues, factValues, 2)
+	row.PumpOperating = newMetricDeviation(planValues, factValues, 3)
+	return row
+}
+
+// newMetricDeviation вычисляет отклонение факта от плана для i-го показателя.
+// Процент не вычисляется, если план отсутствует или равен нулю.
+func newMetricDeviation(plan, fact *[4]float64, i int) models.MetricDeviation {
+	var m models.MetricDeviation
+	if plan != nil {
+		value := plan[i]
+		m.Plan = &value
+	}
+	if fact != nil {
+		value := fact[i]
+		m.Fact = &value
+	}
+	if plan != nil && fact != nil {
+		abs := fact[i] - plan[i]
+		m.Abs = &abs
+		if plan[i] != 0 {
+			percent := abs / plan[i] * 100
+			m.Percent = &percent
+		}
+	}
+	return m
+}
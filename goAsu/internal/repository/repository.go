@@ -0,0 +1,200 @@
+// Package repository описывает доступ к данным, не зависящий от способа хранения.
+// Реализация для PostgreSQL находится в пакете postgres, реализация в памяти,
+// пригодная для тестов и демонстрационного режима, — в пакете memory.
+package repository
+
+import (
+	"context"
+	"errors"
+	"goAsu/internal/civil"
+	"goAsu/internal/models"
+	"goAsu/internal/query"
+	"time"
+)
+
+var (
+	// ErrNotFound возвращается, если запись с заданным ключом не существует,
+	// в том числе из Update и Delete всех репозиториев.
+	ErrNotFound = errors.New("not found")
+	// ErrInUse возвращается при удалении записи, на которую ссылаются другие записи.
+	ErrInUse = errors.New("referenced by other records")
+	// ErrConflict возвращается при создании записи с уже существующим ключом.
+	ErrConflict = errors.New("already exists")
+	// ErrInvalidReference возвращается, если запись ссылается на несуществующую запись.
+	ErrInvalidReference = errors.New("references a missing record")
+	// ErrInvalidValue возвращается, если хранилище отвергло значение поля.
+	ErrInvalidValue = errors.New("invalid value")
+	// ErrPreconditionFailed возвращается функцией проверки, переданной в Put,
+	// если текущая запись не соответствует ожиданиям клиента.
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrCanceled возвращается, если обращение к хранилищу прервано,
+	// потому что клиент отменил запрос или закрыл соединение.
+	ErrCanceled = errors.New("request canceled")
+	// ErrTimeout возвращается, если обращение к хранилищу не уложилось
+	// в отведенное время.
+	ErrTimeout = errors.New("query timed out")
+)
+
+// ConstraintError уточняет ErrConflict, ErrInvalidReference или ErrInvalidValue
+// именем нарушенного ограничения и столбца, если хранилище их сообщает.
+type ConstraintError struct {
+	Err        error
+	Constraint string
+	Column     string
+}
+
+func (e *ConstraintError) Error() string {
+	if e.Constraint != "" {
+		return e.Err.Error() + " (" + e.Constraint + ")"
+	}
+	return e.Err.Error()
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// Store объединяет репозитории всех ресурсов.
+type Store struct {
+	ObjectTypes ObjectTypeRepository
+	Objects     ObjectRepository
+	Wells       WellRepository
+	Histories   WellDayHistoryRepository
+	Plans       WellDayPlanRepository
+	Reports     ReportRepository
+	Audit       AuditRepository
+}
+
+type ObjectTypeRepository interface {
+	List(ctx context.Context) ([]models.ObjectType, error)
+	Get(ctx context.Context, id int) (models.ObjectType, error)
+	// Create сохраняет тип объекта и заполняет его ID.
+	Create(ctx context.Context, objType *models.ObjectType) error
+	Update(ctx context.Context, objType models.ObjectType) error
+	// Delete возвращает ErrInUse, если на тип ссылаются объекты.
+	Delete(ctx context.Context, id int) error
+}
+
+type ObjectRepository interface {
+	// List возвращает страницу объектов с заполненным TypeName и общее число
+	// объектов, удовлетворяющих фильтрам.
+	List(ctx context.Context, q query.List) ([]models.Object, int, error)
+	// Each вызывает fn для каждой записи выборки q по мере чтения из хранилища,
+	// не загружая выборку в память целиком. Ошибка fn прерывает обход.
+	Each(ctx context.Context, q query.List, fn func(models.Object) error) error
+	// Get возвращает объект с заполненным TypeName.
+	Get(ctx context.Context, id int) (models.Object, error)
+	// Create сохраняет объект и заполняет его ID.
+	Create(ctx context.Context, obj *models.Object) error
+	Update(ctx context.Context, obj models.Object) error
+	// Patch блокирует объект id, изменяет его функцией apply и сохраняет
+	// результат, чтобы параллельные изменения не затирали друг друга.
+	// Ошибка apply отменяет изменение. apply не должна обращаться к
+	// хранилищу. Возвращает сохраненный объект с заполненным TypeName.
+	Patch(ctx context.Context, id int, apply func(obj *models.Object) error) (models.Object, error)
+	Delete(ctx context.Context, id int) error
+	// SaveBatch создает объекты без ID, заполняя их ID, и изменяет объекты
+	// с ID. Работает как WellDayHistoryRepository.UpsertBatch.
+	SaveBatch(ctx context.Context, objects []models.Object, atomic bool) ([]error, error)
+}
+
+type WellRepository interface {
+	List(ctx context.Context, q query.List) ([]models.Well, int, error)
+	Each(ctx context.Context, q query.List, fn func(models.Well) error) error
+	Get(ctx context.Context, well int) (models.Well, error)
+	Create(ctx context.Context, well models.Well) error
+	Update(ctx context.Context, well models.Well) error
+	// Patch работает как ObjectRepository.Patch для скважины well.
+	Patch(ctx context.Context, well int, apply func(w *models.Well) error) (models.Well, error)
+	Delete(ctx context.Context, well int) error
+	// UpsertBatch работает как WellDayHistoryRepository.UpsertBatch
+	// для ключа well.
+	UpsertBatch(ctx context.Context, wells []models.Well, atomic bool) ([]error, error)
+}
+
+type WellDayHistoryRepository interface {
+	List(ctx context.Context, q query.List) ([]models.WellDayHistory, int, error)
+	Each(ctx context.Context, q query.List, fn func(models.WellDayHistory) error) error
+	Get(ctx context.Context, well int, dateFact civil.Date) (models.WellDayHistory, error)
+	Create(ctx context.Context, history models.WellDayHistory) error
+	Update(ctx context.Context, history models.WellDayHistory) error
+	Delete(ctx context.Context, well int, dateFact civil.Date) error
+	// Put заменяет запись с ключом (well, date_fact) или создает ее, если
+	// записи нет, и сообщает, создана ли запись. Если check не nil, он
+	// вызывается в той же транзакции с текущей записью (nil, если ее нет),
+	// и его ошибка отменяет запись; так предусловия клиента проверяются
+	// без гонки с параллельными изменениями.
+	Put(ctx context.Context, history models.WellDayHistory, check func(current *models.WellDayHistory) error) (bool, error)
+	// Upsert сохраняет записи в одной транзакции, заменяя существующие
+	// записи с тем же ключом (well, date_fact).
+	Upsert(ctx context.Context, histories []models.WellDayHistory) error
+	// UpsertBatch сохраняет записи в одной транзакции так же, как Upsert,
+	// и возвращает ошибку по каждой записи (nil — запись сохранена).
+	// Если atomic, ошибка любой записи отменяет всю пачку; иначе сохраняются
+	// все записи без ошибок. Ошибка результата означает, что пачка не
+	// сохранена из-за сбоя хранилища, отмены запроса или тайм-аута.
+	UpsertBatch(ctx context.Context, histories []models.WellDayHistory, atomic bool) ([]error, error)
+	// ListAsOf и EachAsOf работают как List и Each, но возвращают записи
+	// в том виде, в каком они были известны системе в момент asOf.
+	ListAsOf(ctx context.Context, q query.List, asOf time.Time) ([]models.WellDayHistory, int, error)
+	EachAsOf(ctx context.Context, q query.List, asOf time.Time, fn func(models.WellDayHistory) error) error
+	// Versions возвращает все версии записи, включая удаленные,
+	// в порядке возрастания RecordedFrom.
+	Versions(ctx context.Context, well int, dateFact civil.Date) ([]models.WellDayHistoryVersion, error)
+}
+
+type WellDayPlanRepository interface {
+	List(ctx context.Context, q query.List) ([]models.WellDayPlan, int, error)
+	Each(ctx context.Context, q query.List, fn func(models.WellDayPlan) error) error
+	Get(ctx context.Context, well int, datePlan civil.Date) (models.WellDayPlan, error)
+	Create(ctx context.Context, plan models.WellDayPlan) error
+	Update(ctx context.Context, plan models.WellDayPlan) error
+	Delete(ctx context.Context, well int, datePlan civil.Date) error
+	// Put работает как WellDayHistoryRepository.Put для ключа (well, date_plan).
+	Put(ctx context.Context, plan models.WellDayPlan, check func(current *models.WellDayPlan) error) (bool, error)
+	// Upsert сохраняет записи в одной транзакции, заменяя существующие
+	// записи с тем же ключом (well, date_plan).
+	Upsert(ctx context.Context, plans []models.WellDayPlan) error
+	// UpsertBatch работает как WellDayHistoryRepository.UpsertBatch.
+	UpsertBatch(ctx context.Context, plans []models.WellDayPlan, atomic bool) ([]error, error)
+}
+
+// PlanFactFilter задает выборку для отчета "план-факт".
+// Пустой список Wells означает все скважины. Если задан AsOf, фактические
+// данные берутся в том виде, в каком они были известны в этот момент.
+type PlanFactFilter struct {
+	Wells    []int64
+	DateFrom civil.Date
+	DateTo   civil.Date
+	AsOf     *time.Time
+}
+
+// RollupFilter задает выборку для свода по иерархии объектов.
+// Level — ngdu, cdng, kust или mest; Period — day, week или month;
+// Source — fact или plan. Hierarchy ограничивает выборку скважинами
+// с указанными кодами уровней иерархии. AsOf допускается только
+// для источника fact и работает так же, как в PlanFactFilter.
+type RollupFilter struct {
+	Level     string
+	Period    string
+	Source    string
+	DateFrom  civil.Date
+	DateTo    civil.Date
+	Hierarchy map[string][]int64
+	AsOf      *time.Time
+}
+
+type ReportRepository interface {
+	PlanFact(ctx context.Context, filter PlanFactFilter) ([]models.PlanFactDeviation, error)
+	Rollup(ctx context.Context, filter RollupFilter) ([]models.ProductionRollup, error)
+	// WellCard собирает карточку скважины на дату date: последние фактические
+	// данные не позже date, план на date и итоги за 30 дней по date включительно.
+	// Если скважины нет, возвращает ErrNotFound.
+	WellCard(ctx context.Context, well int, date civil.Date) (models.WellCard, error)
+}
+
+// AuditRepository читает журнал изменений. Записи журнала создаются
+// репозиториями ресурсов при каждой операции записи.
+type AuditRepository interface {
+	List(ctx context.Context, q query.List) ([]models.AuditEntry, int, error)
+}
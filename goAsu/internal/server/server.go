@@ -0,0 +1,83 @@
+// Package server запускает HTTP-сервер API с ограничениями на время
+// обработки и размер запросов и корректно останавливает его по сигналу.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goAsu/internal/apierror"
+	"goAsu/internal/config"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// New создает сервер с тайм-аутами из cfg. Размер тела запроса
+// ограничивается обработчиком LimitBody.
+func New(cfg config.ServerConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
+
+// Run принимает соединения до получения SIGINT или SIGTERM, после чего
+// перестает принимать новые запросы и ждет завершения выполняемых, но не
+// дольше cfg.ShutdownTimeout. Если заданы файлы сертификата, сервер
+// работает по HTTPS. Run возвращает nil после корректной остановки.
+func Run(srv *http.Server, cfg config.ServerConfig) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		if cfg.TLSCertFile != "" {
+			log.Printf("Listening on %s (HTTPS)", srv.Addr)
+			errc <- srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+			return
+		}
+		log.Printf("Listening on %s", srv.Addr)
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	// Повторный сигнал завершает процесс, не дожидаясь запросов.
+	stop()
+
+	log.Println("Shutting down, waiting for in-flight requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	if err := <-errc; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// LimitBody ограничивает размер тела запроса limit байтами. Запрос с большим
+// Content-Length сразу получает 413; при чтении тела без длины обработчик
+// получает ошибку *http.MaxBytesError.
+func LimitBody(limit int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			apierror.Write(w, r, http.StatusRequestEntityTooLarge, apierror.PayloadTooLarge, "Request body is too large", nil)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,214 @@
+// Package validation проверяет дневные записи скважин перед сохранением.
+//
+// Встроенные правила отражают физический смысл показателей: скважина должна
+// существовать, дата — быть задана (фактическая дата — не позже текущего
+// дня), показатели — быть конечными числами, debit, ee_consume и expenses
+// не могут быть отрицательными, а время работы насоса — превышать 24 часа.
+// Формат даты YYYY-MM-DD проверяется при разборе записи. Поверх встроенных
+// правил конфигурация задает допустимые диапазоны показателей для всех
+// скважин, месторождения или отдельной скважины.
+package validation
+
+import (
+	"context"
+	"fmt"
+	"goAsu/internal/civil"
+	"goAsu/internal/config"
+	"goAsu/internal/models"
+	"goAsu/internal/query"
+	"goAsu/internal/repository"
+	"math"
+	"time"
+)
+
+const (
+	Fact = "fact"
+	Plan = "plan"
+)
+
+// Validator проверяет записи истории и планов.
+type Validator struct {
+	rules []config.RangeRule
+	wells repository.WellRepository
+	now   func() time.Time
+}
+
+// New создает Validator с правилами диапазонов cfg. Скважины записей
+// ищутся в wells: без них нельзя применить правила месторождения.
+func New(cfg config.ValidationConfig, wells repository.WellRepository) *Validator {
+	return &Validator{rules: cfg.Rules, wells: wells, now: time.Now}
+}
+
+// record — проверяемая запись, общая для истории и планов.
+type record struct {
+	source    string
+	well      int
+	date      civil.Date
+	dateField string
+	metrics   [4]float64
+}
+
+var metricFields = [4]string{"debit", "ee_consume", "expenses", "pump_operating"}
+
+func historyRecord(h models.WellDayHistory) record {
+	return record{Fact, h.Well, h.DateFact, "date_fact", [4]float64{h.Debit, h.EEConsume, h.Expenses, h.PumpOperating}}
+}
+
+func planRecord(p models.WellDayPlan) record {
+	return record{Plan, p.Well, p.DatePlan, "date_plan", [4]float64{p.Debit, p.EEConsume, p.Expenses, p.PumpOperating}}
+}
+
+// History проверяет запись истории. Ошибка возвращается только при сбое
+// чтения скважины из хранилища; нарушения правил — в списке FieldError.
+func (v *Validator) History(ctx context.Context, h models.WellDayHistory) ([]models.FieldError, error) {
+	return v.one(ctx, historyRecord(h))
+}
+
+// Plan проверяет плановую запись.
+func (v *Validator) Plan(ctx context.Context, p models.WellDayPlan) ([]models.FieldError, error) {
+	return v.one(ctx, planRecord(p))
+}
+
+// Histories проверяет набор записей истории, читая скважины одним запросом.
+// i-й элемент результата содержит ошибки i-й записи.
+func (v *Validator) Histories(ctx context.Context, histories []models.WellDayHistory) ([][]models.FieldError, error) {
+	records := make([]record, len(histories))
+	for i, h := range histories {
+		records[i] = historyRecord(h)
+	}
+	return v.many(ctx, records)
+}
+
+// Plans проверяет набор плановых записей, читая скважины одним запросом.
+func (v *Validator) Plans(ctx context.Context, plans []models.WellDayPlan) ([][]models.FieldError, error) {
+	records := make([]record, len(plans))
+	for i, p := range plans {
+		records[i] = planRecord(p)
+	}
+	return v.many(ctx, records)
+}
+
+func (v *Validator) one(ctx context.Context, rec record) ([]models.FieldError, error) {
+	result, err := v.many(ctx, []record{rec})
+	if err != nil {
+		return nil, err
+	}
+	return result[0], nil
+}
+
+func (v *Validator) many(ctx context.Context, records []record) ([][]models.FieldError, error) {
+	wells, err := v.lookup(ctx, records)
+	if err != nil {
+		return nil, err
+	}
+	today := civil.Of(v.now())
+	result := make([][]models.FieldError, len(records))
+	for i, rec := range records {
+		result[i] = v.check(rec, wells, today)
+	}
+	return result, nil
+}
+
+// lookup читает скважины, на которые ссылаются записи.
+func (v *Validator) lookup(ctx context.Context, records []record) (map[int]models.Well, error) {
+	var ids []interface{}
+	seen := map[int]bool{}
+	for _, rec := range records {
+		if rec.well > 0 && !seen[rec.well] {
+			seen[rec.well] = true
+			ids = append(ids, int64(rec.well))
+		}
+	}
+	wells := map[int]models.Well{}
+	if len(ids) == 0 {
+		return wells, nil
+	}
+	q := query.List{Filters: []query.Filter{{Field: "well", Op: query.Eq, Kind: query.Int, Values: ids}}}
+	err := v.wells.Each(ctx, q, func(well models.Well) error {
+		wells[well.Well] = well
+		return nil
+	})
+	return wells, err
+}
+
+func (v *Validator) check(rec record, wells map[int]models.Well, today civil.Date) []models.FieldError {
+	errs := []models.FieldError{}
+	fail := func(field, format string, args ...interface{}) {
+		errs = append(errs, models.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	well, known := wells[rec.well]
+	switch {
+	case rec.well <= 0:
+		fail("well", "must be a positive integer")
+	case !known:
+		fail("well", "well %d does not exist", rec.well)
+	}
+
+	if rec.date.IsZero() {
+		fail(rec.dateField, "is required")
+	} else if rec.source == Fact && rec.date.After(today) {
+		fail(rec.dateField, "must not be in the future")
+	}
+
+	for i, field := range metricFields {
+		value := rec.metrics[i]
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			fail(field, "must be a finite number")
+			continue
+		}
+		if value < 0 {
+			fail(field, "must not be negative")
+			continue
+		}
+		if field == "pump_operating" && value > 24 {
+			fail(field, "must not exceed 24 hours")
+			continue
+		}
+		rule, ok := v.rule(field, rec.source, rec.well, well.Mest)
+		if !ok {
+			continue
+		}
+		switch {
+		case rule.Min != nil && rule.Max != nil && (value < *rule.Min || value > *rule.Max):
+			fail(field, "must be between %g and %g", *rule.Min, *rule.Max)
+		case rule.Min != nil && rule.Max == nil && value < *rule.Min:
+			fail(field, "must be at least %g", *rule.Min)
+		case rule.Max != nil && rule.Min == nil && value > *rule.Max:
+			fail(field, "must be at most %g", *rule.Max)
+		}
+	}
+	return errs
+}
+
+// rule выбирает наиболее конкретное правило для показателя: правило скважины
+// важнее правила месторождения, а оно — общего; при равенстве правило
+// для факта или плана важнее правила для обоих источников.
+func (v *Validator) rule(field, source string, well, mest int) (config.RangeRule, bool) {
+	best, bestRank := config.RangeRule{}, -1
+	for _, rule := range v.rules {
+		if rule.Field != field || (rule.Source != "" && rule.Source != source) {
+			continue
+		}
+		rank := 0
+		switch {
+		case rule.Well != 0:
+			if rule.Well != well {
+				continue
+			}
+			rank = 4
+		case rule.Mest != 0:
+			if mest == 0 || rule.Mest != mest {
+				continue
+			}
+			rank = 2
+		}
+		if rule.Source != "" {
+			rank++
+		}
+		if rank > bestRank {
+			best, bestRank = rule, rank
+		}
+	}
+	return best, bestRank >= 0
+}
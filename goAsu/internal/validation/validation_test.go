@@ -0,0 +1,164 @@
+package validation
+
+import (
+	"context"
+	"goAsu/internal/civil"
+	"goAsu/internal/config"
+	"goAsu/internal/models"
+	"goAsu/internal/repository/memory"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+// newTestValidator возвращает Validator поверх демонстрационного хранилища,
+// в которое добавлена скважина 4457 на отдельном месторождении, и с
+// текущей датой 2024-12-10.
+func newTestValidator(t *testing.T, rules ...config.RangeRule) *Validator {
+	t.Helper()
+	ctx := context.Background()
+	store := memory.NewDemo()
+	mest := models.Object{Name: "Южное", Type: 4}
+	if err := store.Objects.Create(ctx, &mest); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Wells.Create(ctx, models.Well{Well: 4457, NGDU: 1, CDNG: 2, Kust: 4, Mest: mest.ID}); err != nil {
+		t.Fatal(err)
+	}
+	v := New(config.ValidationConfig{Rules: rules}, store.Wells)
+	v.now = func() time.Time { return time.Date(2024, time.December, 10, 12, 0, 0, 0, time.UTC) }
+	return v
+}
+
+var today = civil.Date{Year: 2024, Month: time.December, Day: 10}
+
+func TestBuiltInRules(t *testing.T) {
+	valid := models.WellDayHistory{Well: 4455, DateFact: today, Debit: 18, EEConsume: 54, Expenses: 4.5, PumpOperating: 24}
+	tests := []struct {
+		name   string
+		modify func(*models.WellDayHistory)
+		want   []models.FieldError
+	}{
+		{"valid", func(*models.WellDayHistory) {}, nil},
+		{"well not positive", func(h *models.WellDayHistory) { h.Well = 0 }, []models.FieldError{{Field: "well", Message: "must be a positive integer"}}},
+		{"unknown well", func(h *models.WellDayHistory) { h.Well = 99 }, []models.FieldError{{Field: "well", Message: "well 99 does not exist"}}},
+		{"missing date", func(h *models.WellDayHistory) { h.DateFact = civil.Date{} }, []models.FieldError{{Field: "date_fact", Message: "is required"}}},
+		{"future date", func(h *models.WellDayHistory) { h.DateFact = today.AddDays(1) }, []models.FieldError{{Field: "date_fact", Message: "must not be in the future"}}},
+		{"negative debit", func(h *models.WellDayHistory) { h.Debit = -1 }, []models.FieldError{{Field: "debit", Message: "must not be negative"}}},
+		{"infinite expenses", func(h *models.WellDayHistory) { h.Expenses = math.Inf(1) }, []models.FieldError{{Field: "expenses", Message: "must be a finite number"}}},
+		{"pump over 24 hours", func(h *models.WellDayHistory) { h.PumpOperating = 24.5 }, []models.FieldError{{Field: "pump_operating", Message: "must not exceed 24 hours"}}},
+		{
+			"several errors",
+			func(h *models.WellDayHistory) { h.Well, h.EEConsume = -5, math.NaN() },
+			[]models.FieldError{{Field: "well", Message: "must be a positive integer"}, {Field: "ee_consume", Message: "must be a finite number"}},
+		},
+	}
+	v := newTestValidator(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := valid
+			tt.modify(&h)
+			got, err := v.History(context.Background(), h)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertFieldErrors(t, got, tt.want)
+		})
+	}
+}
+
+func TestPlanDateMayBeInFuture(t *testing.T) {
+	v := newTestValidator(t)
+	got, err := v.Plan(context.Background(), models.WellDayPlan{Well: 4455, DatePlan: today.AddDays(30), Debit: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFieldErrors(t, got, nil)
+}
+
+func TestRangeRules(t *testing.T) {
+	v := newTestValidator(t,
+		config.RangeRule{Field: "debit", Max: ptr(100)},
+		config.RangeRule{Field: "debit", Mest: 6, Max: ptr(50)},
+		config.RangeRule{Field: "debit", Well: 4456, Max: ptr(80)},
+		config.RangeRule{Field: "ee_consume", Max: ptr(1000)},
+		config.RangeRule{Field: "ee_consume", Source: Plan, Min: ptr(10)},
+		config.RangeRule{Field: "expenses", Min: ptr(1), Max: ptr(5)},
+	)
+	tests := []struct {
+		name   string
+		source string
+		well   int
+		field  string
+		value  float64
+		want   string
+	}{
+		{"general rule", Fact, 4457, "debit", 150, "must be at most 100"},
+		{"within general rule", Fact, 4457, "debit", 90, ""},
+		{"mest rule overrides general", Fact, 4455, "debit", 60, "must be at most 50"},
+		{"well rule overrides mest", Fact, 4456, "debit", 60, ""},
+		{"well rule", Fact, 4456, "debit", 90, "must be at most 80"},
+		{"plan rule", Plan, 4455, "ee_consume", 5, "must be at least 10"},
+		{"plan rule does not apply to facts", Fact, 4455, "ee_consume", 5, ""},
+		{"general rule for both sources", Fact, 4455, "ee_consume", 1500, "must be at most 1000"},
+		{"plan rule replaces general rule", Plan, 4455, "ee_consume", 1500, ""},
+		{"range below", Fact, 4455, "expenses", 0.5, "must be between 1 and 5"},
+		{"range above", Plan, 4455, "expenses", 6, "must be between 1 and 5"},
+		{"field without rules", Fact, 4455, "pump_operating", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			metrics := map[string]float64{"debit": 10, "ee_consume": 20, "expenses": 2, "pump_operating": 12}
+			metrics[tt.field] = tt.value
+			var got []models.FieldError
+			var err error
+			if tt.source == Fact {
+				got, err = v.History(ctx, models.WellDayHistory{Well: tt.well, DateFact: today,
+					Debit: metrics["debit"], EEConsume: metrics["ee_consume"], Expenses: metrics["expenses"], PumpOperating: metrics["pump_operating"]})
+			} else {
+				got, err = v.Plan(ctx, models.WellDayPlan{Well: tt.well, DatePlan: today,
+					Debit: metrics["debit"], EEConsume: metrics["ee_consume"], Expenses: metrics["expenses"], PumpOperating: metrics["pump_operating"]})
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []models.FieldError
+			if tt.want != "" {
+				want = []models.FieldError{{Field: tt.field, Message: tt.want}}
+			}
+			assertFieldErrors(t, got, want)
+		})
+	}
+}
+
+func TestHistoriesBatch(t *testing.T) {
+	v := newTestValidator(t, config.RangeRule{Field: "debit", Mest: 6, Max: ptr(50)})
+	got, err := v.Histories(context.Background(), []models.WellDayHistory{
+		{Well: 4455, DateFact: today, Debit: 60},
+		{Well: 4457, DateFact: today, Debit: 60},
+		{Well: 99, DateFact: today},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d results, want 3", len(got))
+	}
+	assertFieldErrors(t, got[0], []models.FieldError{{Field: "debit", Message: "must be at most 50"}})
+	assertFieldErrors(t, got[1], nil)
+	assertFieldErrors(t, got[2], []models.FieldError{{Field: "well", Message: "well 99 does not exist"}})
+}
+
+func assertFieldErrors(t *testing.T, got, want []models.FieldError) {
+	t.Helper()
+	if len(got) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("errors = %+v, want %+v", got, want)
+	}
+}